@@ -2,6 +2,7 @@ package html5
 
 import (
 	"fmt"
+	"io"
 	"reflect"
 	"strings"
 
@@ -34,9 +35,10 @@ func (a *AttributeNode) Apply(n Node) error {
 }
 
 func appendAttribute(tc *templateCompiler, a *AttributeNode) error {
-	if _, err := fmt.Fprintf(tc, " %s=\"", a.Name); err != nil {
-		return err
-	}
+	tc.ensureBuffer()
+	tc.pending.WriteByte(' ')
+	tc.pending.WriteString(a.Name)
+	tc.pending.WriteString("=\"")
 
 	// Different attributes require different levels of trust (e.g. href
 	// contains URLs).
@@ -44,6 +46,8 @@ func appendAttribute(tc *templateCompiler, a *AttributeNode) error {
 	if !ok {
 		if strings.HasPrefix(a.Name, "data-") {
 			reqTrust = safe.Default
+		} else if strings.HasPrefix(a.Name, "aria-") {
+			reqTrust = safe.AttributeSafe
 		} else {
 			reqTrust = safe.FullyTrusted
 		}
@@ -56,19 +60,44 @@ func appendAttribute(tc *templateCompiler, a *AttributeNode) error {
 		if err != nil {
 			return err
 		}
-		_, err = fmt.Fprintf(tc, "%s\"", s)
+		tc.pending.WriteString(s)
+		tc.pending.WriteByte('"')
+		return nil
+	case Translatable:
+		if !v.Check(reqTrust) {
+			return fmt.Errorf("%v %w %v", v, safe.ErrStringUntrusted, reqTrust)
+		}
+		if err := tc.appendMessage(v, func(w io.Writer, s string) error {
+			_, err := io.WriteString(w, s)
+			return err
+		}); err != nil {
+			return err
+		}
+		_, err := tc.WriteString("\"")
+		return err
+	case localeDir:
+		tc.appendChunk(dirChunk{})
+		_, err := tc.WriteString("\"")
+		return err
+	case csrfToken:
+		tc.appendChunk(csrfChunk{})
+		_, err := tc.WriteString("\"")
 		return err
 	case bindings.Var:
-		tc.appendVar(v, reqTrust)
-		_, err := fmt.Fprint(tc, "\"")
+		if _, err := tc.appendVar(v, reqTrust); err != nil {
+			return err
+		}
+		_, err := tc.WriteString("\"")
 		return err
 	default:
-		return fmt.Errorf("value must be safe.String or *bindings.Var, %v (%v) is neither", v, reflect.TypeOf(v))
+		return fmt.Errorf("value must be safe.String, *bindings.Var or Translatable, %v (%v) is neither", v, reflect.TypeOf(v))
 	}
 }
 
 // Lists the required trust level for the content of known HTML attributes. If
-// an attribute is not on this list, then assume FullyTrusted is required.
+// an attribute is not on this list, then assume FullyTrusted is required,
+// except for data- attributes, which require none, and aria- attributes, which
+// are AttributeSafe.
 //
 // Current spec: https://html.spec.whatwg.org/multipage/indices.html#attributes-3
 var requiredTrustPerAttribute = map[string]safe.TrustLevel{
@@ -181,7 +210,7 @@ var requiredTrustPerAttribute = map[string]safe.TrustLevel{
 	"title":           safe.AttributeSafe,
 	"type":            safe.FullyTrusted,
 	"usemap":          safe.URLSafe,
-	"value":           safe.FullyTrusted,
+	"value":           safe.AttributeSafe,
 	"width":           safe.AttributeSafe,
 	"wrap":            safe.AttributeSafe,
 	"xmlns":           safe.URLSafe,
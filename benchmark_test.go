@@ -0,0 +1,97 @@
+package html5
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// benchmarkPage compiles a small page with bound text and attributes, a
+// switch and a subsection, and returns it with values for every Var.
+func benchmarkPage(tb testing.TB, opts *CompileOptions) (*Template, *bindings.ValueMap) {
+	tb.Helper()
+	var m bindings.Map
+	title := bindings.Declare("title", safe.Default)
+	input := Element("html",
+		Element("head", Element("title", Text(title))),
+		Element("body",
+			Element("h1", Attribute("class", safe.Const("title")), Text(title)),
+			&SwitchNode{
+				Cases:   []Case{{Condition: IsSet(bindings.Declare("subtitle", safe.Default)), Output: Element("h2", Text(bindings.Declare("subtitle", safe.Default)))}},
+				Default: Element("h2", Text(safe.Const("No subtitle"))),
+			},
+			Element("ul", &SubsectionNode{
+				Name: "items",
+				Prototype: Element("li",
+					Element("a", Attribute("href", bindings.Declare("url", safe.URLSafe)), Text(bindings.Declare("label", safe.Default)))),
+			}),
+			Element("p", Text(bindings.Declare("body", safe.Default)))))
+	tmpl, err := Compile(input, &m, opts)
+	if err != nil {
+		tb.Fatalf("Compile: %v", err)
+	}
+
+	items := m.Nest("items")
+	url, _ := items.Lookup("url")
+	label, _ := items.Lookup("label")
+	rows := make([]*bindings.ValueMap, 10)
+	for i := range rows {
+		rows[i] = items.MustBind(
+			url.Bind(safe.Bless(safe.URLSafe, fmt.Sprintf("/items/%d", i))),
+			label.Bind(safe.EscapeText(fmt.Sprintf("Item <%d>", i))))
+	}
+	titleVar, _ := m.Lookup("title")
+	bodyVar, _ := m.Lookup("body")
+	vm := m.MustBind(
+		titleVar.BindConst("Benchmark"),
+		bodyVar.Bind(safe.EscapeText("Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua.")),
+		items.BindSeries(rows...))
+	return tmpl, vm
+}
+
+// writerOnly hides the WriteString method of the underlying writer.
+type writerOnly struct {
+	io.Writer
+}
+
+func BenchmarkSmallPage(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		opts *CompileOptions
+		w    io.Writer
+	}{
+		{name: "Compact", opts: &Compact, w: ioutil.Discard},
+		{name: "Tidy", opts: &Tidy, w: ioutil.Discard},
+		{name: "Wrapped", opts: &CompileOptions{Indent: "  ", TextWidth: 40}, w: ioutil.Discard},
+		{name: "WriterOnly", opts: &Compact, w: writerOnly{ioutil.Discard}},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			tmpl, vm := benchmarkPage(b, bc.opts)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := tmpl.GenerateHTML(bc.w, vm); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateHTMLAllocs(t *testing.T) {
+	for _, opts := range []*CompileOptions{&Compact, &Tidy, &Debug} {
+		tmpl, vm := benchmarkPage(t, opts)
+		allocs := testing.AllocsPerRun(100, func() {
+			if err := tmpl.GenerateHTML(ioutil.Discard, vm); err != nil {
+				t.Fatal(err)
+			}
+		})
+		if allocs != 0 {
+			t.Errorf("GenerateHTML with options %v => %v allocations per run, wanted 0", opts, allocs)
+		}
+	}
+}
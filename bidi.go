@@ -0,0 +1,146 @@
+package html5
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/i18n"
+	"github.com/the80srobot/html5/safe"
+)
+
+// BidiIsolation controls how a TextNode is isolated from the surrounding
+// text. Isolation keeps the direction of the text (e.g. an Arabic user name)
+// from reordering the punctuation and words around it.
+type BidiIsolation int16
+
+const (
+	// NoIsolation writes the text as is.
+	NoIsolation BidiIsolation = iota
+	// IsolateBDI wraps the text in a <bdi> element.
+	IsolateBDI
+	// IsolateUnicode wraps the text in the Unicode FIRST STRONG ISOLATE and
+	// POP DIRECTIONAL ISOLATE characters. Unlike <bdi>, this also works in
+	// elements that can't contain markup, such as <title>.
+	IsolateUnicode
+)
+
+func (bi BidiIsolation) String() string {
+	switch bi {
+	case NoIsolation:
+		return "NoIsolation"
+	case IsolateBDI:
+		return "IsolateBDI"
+	case IsolateUnicode:
+		return "IsolateUnicode"
+	default:
+		return fmt.Sprintf("BidiIsolation(%d)", bi)
+	}
+}
+
+func (bi BidiIsolation) delimiters() (string, string) {
+	switch bi {
+	case IsolateBDI:
+		return "<bdi>", "</bdi>"
+	case IsolateUnicode:
+		return "\u2068", "\u2069"
+	default:
+		return "", ""
+	}
+}
+
+// Isolate returns a TextNode with the value, isolated with the <bdi> element.
+// Use it for text of unknown direction, like user names.
+func Isolate(value Value) *TextNode {
+	return &TextNode{Value: value, Isolate: IsolateBDI}
+}
+
+// DirAuto returns the attribute dir="auto", which lets the browser pick the
+// direction of a form field from its contents. (See also
+// CompileOptions.AutoDir.)
+func DirAuto() *AttributeNode {
+	return Attribute("dir", safe.Const("auto"))
+}
+
+// LocaleDir returns a Value for the dir attribute, which is "rtl" if the
+// locale being rendered is written right to left, and "ltr" otherwise. (See
+// i18n.Dir.)
+func LocaleDir() Value {
+	return localeDir{}
+}
+
+type localeDir struct{}
+
+// Check always succeeds, because the value is one of two constants.
+func (localeDir) Check(safe.TrustLevel) bool {
+	return true
+}
+
+func (localeDir) String() string {
+	return "LocaleDir()"
+}
+
+// dirChunk writes the direction of the locale being rendered.
+type dirChunk struct{}
+
+func (dirChunk) text(locale string) string {
+	return i18n.Dir(locale)
+}
+
+func (dc dirChunk) build(w io.Writer, _ *bindings.ValueMap, rc renderContext) error {
+	return writeString(w, dc.text(rc.locale))
+}
+
+func (dc dirChunk) size(_ *bindings.ValueMap, rc renderContext) int {
+	return len(dc.text(rc.locale))
+}
+
+func (dc dirChunk) fingerprint(h *fingerprinter, _ *bindings.ValueMap, rc renderContext) {
+	h.writeString(dc.text(rc.locale))
+}
+
+func (dirChunk) String() string {
+	return "dir{}"
+}
+
+// Input types whose value is free text, and so can be in either direction.
+var textInputTypes = map[string]bool{
+	"":       true,
+	"search": true,
+	"text":   true,
+}
+
+// autoDirAttributes returns the attributes with a dir attribute added, if the
+// element should have one under CompileOptions.AutoDir.
+func autoDirAttributes(name string, attributes []AttributeNode) []AttributeNode {
+	inputType := ""
+	for _, a := range attributes {
+		switch a.Name {
+		case "dir":
+			return attributes
+		case "type":
+			if s, ok := a.Value.(safe.String); ok {
+				inputType = s.String()
+			} else {
+				// Can't tell what kind of input a bound type is.
+				inputType = "?"
+			}
+		}
+	}
+
+	var dir Value
+	switch name {
+	case "html", "body":
+		dir = LocaleDir()
+	case "textarea":
+		dir = safe.Const("auto")
+	case "input":
+		if !textInputTypes[inputType] {
+			return attributes
+		}
+		dir = safe.Const("auto")
+	default:
+		return attributes
+	}
+	return append(attributes[:len(attributes):len(attributes)], AttributeNode{Name: "dir", Value: dir})
+}
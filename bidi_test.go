@@ -0,0 +1,112 @@
+package html5
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestIsolate(t *testing.T) {
+	for _, tc := range []struct {
+		comment string
+		input   Node
+		values  []bindings.BindArg
+		output  string
+	}{
+		{
+			comment: "bdi",
+			input:   Element("p", Isolate(bindings.Declare("name", safe.Default)), Text(safe.Const(": 3 posts"))),
+			values:  []bindings.BindArg{{Name: "name", Value: safe.EscapeText("إيان")}},
+			output:  "<p><bdi>إيان</bdi>: 3 posts</p>",
+		},
+		{
+			comment: "unicode",
+			input:   Element("title", &TextNode{Value: bindings.Declare("name", safe.Default), Isolate: IsolateUnicode}),
+			values:  []bindings.BindArg{{Name: "name", Value: safe.EscapeText("<אבי>")}},
+			output:  "<title>\u2068&lt;אבי&gt;\u2069</title>",
+		},
+		{
+			comment: "static",
+			input:   &TextNode{Value: safe.Const("Hello"), Isolate: IsolateBDI},
+			output:  "<bdi>Hello</bdi>",
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			if diff := cmp.Diff(tc.output, mustGenerateHTML(t, tc.input, &Compact, tc.values)); diff != "" {
+				t.Errorf("GenerateHTML(%v, %v)\n => (-)wanted vs (+)got:\n%s", tc.input, tc.values, diff)
+			}
+		})
+	}
+}
+
+func TestAutoDir(t *testing.T) {
+	input := Element("html",
+		Element("body",
+			Element("form",
+				Element("input", Attribute("name", safe.Const("q"))),
+				Element("input", Attribute("type", safe.Const("checkbox"))),
+				Element("input", Attribute("type", safe.Const("search")), Attribute("dir", safe.Const("ltr"))),
+				Element("textarea"))))
+	opts := &CompileOptions{Compact: true, AutoDir: true}
+
+	for _, tc := range []struct {
+		comment string
+		opts    *CompileOptions
+		locale  string
+		output  string
+	}{
+		{
+			comment: "left to right",
+			opts:    opts,
+			locale:  "en",
+			output:  `<html dir="ltr"><body dir="ltr"><form><input name="q" dir="auto"><input type="checkbox"><input type="search" dir="ltr"><textarea dir="auto"></textarea></form></body></html>`,
+		},
+		{
+			comment: "right to left",
+			opts:    opts,
+			locale:  "ar-EG",
+			output:  `<html dir="rtl"><body dir="rtl"><form><input name="q" dir="auto"><input type="checkbox"><input type="search" dir="ltr"><textarea dir="auto"></textarea></form></body></html>`,
+		},
+		{
+			comment: "off",
+			opts:    &Compact,
+			locale:  "ar",
+			output:  `<html><body><form><input name="q"><input type="checkbox"><input type="search" dir="ltr"><textarea></textarea></form></body></html>`,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			var m bindings.Map
+			tmpl := MustCompile(input, &m, tc.opts)
+			var sb strings.Builder
+			if err := tmpl.Render(&sb, m.MustBind(), &RenderOptions{Locale: tc.locale}); err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if diff := cmp.Diff(tc.output, sb.String()); diff != "" {
+				t.Errorf("Render(%v, %q)\n => (-)wanted vs (+)got:\n%s", input, tc.locale, diff)
+			}
+		})
+	}
+
+	if got := len(Element("html").Attributes); got != 0 {
+		t.Errorf("AutoDir modified the Node tree: html has %d attributes", got)
+	}
+}
+
+func TestLocaleDirVariants(t *testing.T) {
+	input := Element("html", Attribute("dir", LocaleDir()), Element("body", Text(T("hello", "Hello"))))
+	var m bindings.Map
+	tmpl := MustCompile(input, &m, &CompileOptions{Compact: true, Catalog: testCatalog(), Locales: []string{"he", "en"}})
+	if got := len(tmpl.variant("he")); got != 1 {
+		t.Errorf("len(variant(he)) => %d, wanted a single static chunk", got)
+	}
+	var sb strings.Builder
+	if err := tmpl.Render(&sb, m.MustBind(), &RenderOptions{Locale: "he"}); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if diff := cmp.Diff(`<html dir="rtl"><body>שלום</body></html>`, sb.String()); diff != "" {
+		t.Errorf("Render(%v, he)\n => (-)wanted vs (+)got:\n%s", input, diff)
+	}
+}
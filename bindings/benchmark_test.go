@@ -75,3 +75,64 @@ func BenchmarkLookupValueMap100Bindings(b *testing.B) {
 		}
 	}
 }
+
+func BenchmarkLookupFrozenMapByName(b *testing.B) {
+	var m Map
+	for i := 0; i < 100; i++ {
+		m.Declare(fmt.Sprintf("binding_%d", i), safe.Default)
+	}
+	m.Freeze()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := m.Lookup("binding_50"); !ok {
+			b.Fatal("binding_50 not found")
+		}
+	}
+}
+
+func BenchmarkLookupValueMapStream(b *testing.B) {
+	var m Map
+	nested := m.Nest("rows")
+	v := nested.Declare("value", safe.Default)
+	rows := make([]*ValueMap, 10)
+	for i := range rows {
+		rows[i] = nested.MustBind(v.Bind(safe.Bless(safe.Default, fmt.Sprintf("val_%d", i))))
+	}
+	vm := m.MustBind(nested.BindSeries(rows...))
+	m.Freeze()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, row := range vm.GetStream(nested).(ValueSeries) {
+			if _, ok := row.Lookup(v); !ok {
+				b.Fatal("value not set")
+			}
+		}
+	}
+}
+
+func TestLookupAllocs(t *testing.T) {
+	var m Map
+	nested := m.Nest("rows")
+	v := nested.Declare("value", safe.Default)
+	d := m.Declare("default", safe.Default)
+	if err := m.SetDefault(d, safe.Const("default")); err != nil {
+		t.Fatal(err)
+	}
+	vm := m.MustBind(nested.BindSeries(nested.MustBind(v.BindConst("value"))))
+	m.Freeze()
+
+	allocs := testing.AllocsPerRun(100, func() {
+		m.Lookup("default")
+		vm.Lookup(d)
+		for _, row := range vm.GetStream(nested).(ValueSeries) {
+			row.Lookup(v)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("looking up values => %v allocations per run, wanted 0", allocs)
+	}
+}
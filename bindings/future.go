@@ -0,0 +1,62 @@
+package bindings
+
+import "sync"
+
+// Future is a ValueStream whose rows are resolved later, usually by another
+// goroutine. Iterating over a Future blocks until it's resolved. After that,
+// it behaves like a ValueSeries, and can be iterated any number of times.
+//
+// Futures are meant for slow parts of a page: the rest of the page can render,
+// while the Future's rows are still being loaded. (See html5.DeferredNode.)
+type Future struct {
+	once sync.Once
+	done chan struct{}
+	rows ValueSeries
+}
+
+// NewFuture returns an unresolved Future.
+func NewFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+// FutureFromChannel returns a Future that collects ValueMaps from the channel,
+// and resolves when the channel is closed.
+func FutureFromChannel(ch <-chan *ValueMap) *Future {
+	f := NewFuture()
+	go func() {
+		var rows ValueSeries
+		for vm := range ch {
+			rows = append(rows, vm)
+		}
+		f.Resolve(rows...)
+	}()
+	return f
+}
+
+// Resolve sets the rows of the Future, and unblocks anyone iterating over it.
+// Only the first call to Resolve has any effect. Resolving with no rows is
+// like binding an empty ValueSeries.
+func (f *Future) Resolve(rows ...*ValueMap) {
+	f.once.Do(func() {
+		f.rows = rows
+		close(f.done)
+	})
+}
+
+// Done returns a channel that's closed when the Future is resolved.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Stream returns an iterator over the rows of the Future. The iterator blocks
+// until the Future is resolved.
+func (f *Future) Stream() ValueIterator {
+	var next ValueIterator
+	return func() *ValueMap {
+		if next == nil {
+			<-f.done
+			next = f.rows.Stream()
+		}
+		return next()
+	}
+}
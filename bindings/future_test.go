@@ -0,0 +1,66 @@
+package bindings
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/safe"
+)
+
+func collect(stream ValueStream, v Var) []string {
+	var got []string
+	next := stream.Stream()
+	for vm := next(); vm != nil; vm = next() {
+		got = append(got, vm.GetString(v))
+	}
+	return got
+}
+
+func TestFuture(t *testing.T) {
+	var m Map
+	v := m.Declare("v", safe.Default)
+
+	f := NewFuture()
+	select {
+	case <-f.Done():
+		t.Fatal("NewFuture() is already resolved")
+	default:
+	}
+
+	results := make(chan []string)
+	go func() { results <- collect(f, v) }()
+	select {
+	case got := <-results:
+		t.Fatalf("iterating over an unresolved Future returned %v, wanted it to block", got)
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	f.Resolve(m.MustBind(v.BindConst("a")), m.MustBind(v.BindConst("b")))
+	f.Resolve(m.MustBind(v.BindConst("ignored")))
+	want := []string{"a", "b"}
+	if diff := cmp.Diff(want, <-results); diff != "" {
+		t.Errorf("Future rows => (-)wanted vs (+)got:\n%s", diff)
+	}
+	// Resolved Futures can be iterated again.
+	if diff := cmp.Diff(want, collect(f, v)); diff != "" {
+		t.Errorf("Future rows on the second iteration => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
+
+func TestFutureFromChannel(t *testing.T) {
+	var m Map
+	v := m.Declare("v", safe.Default)
+
+	ch := make(chan *ValueMap)
+	f := FutureFromChannel(ch)
+	go func() {
+		ch <- m.MustBind(v.BindConst("a"))
+		ch <- m.MustBind(v.BindConst("b"))
+		close(ch)
+	}()
+	<-f.Done()
+	if diff := cmp.Diff([]string{"a", "b"}, collect(f, v)); diff != "" {
+		t.Errorf("FutureFromChannel rows => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
@@ -12,11 +12,14 @@ import (
 // GetStringByName looks up the value of a Var with the given name. Doesn't
 // distinguish between empty string and no value. If the Var was previously
 // undeclared, then this will have the side effect of declaring it in the
-// associated Map.
+// associated Map, unless the Map is frozen.
 //
 // This is slower than ValueMap.GetString and should only be used for debugging.
 func GetStringByName(vm *ValueMap, name string) string {
-	v := vm.Vars.Declare(name, safe.Default)
+	v, err := vm.Vars.TryDeclare(name, safe.Default)
+	if err != nil {
+		return ""
+	}
 	return vm.GetString(v)
 }
 
@@ -54,7 +57,9 @@ func Bind(vm *ValueMap, args ...BindArg) error {
 //
 // As a convenience, if Var or NestedMap are not available, but Name is a
 // non-empty string, then Bind will lookup or declare the Var or Map
-// automatically. This is slower than providing the Var or NestedMap.
+// automatically. This is slower than providing the Var or NestedMap. If the Map
+// is frozen, then undeclared names are ignored, or rejected with ErrUndefined if
+// the Map is Strict.
 //
 // Optionally, if TrustRequirement is specified, it will be treated as an extra
 // requirement on top of the Var's requirement, and Var will be promoted to
@@ -127,9 +132,16 @@ func (a BindArg) DebugDump(w io.Writer, depth int) {
 func bindSubsection(vm *ValueMap, arg BindArg) error {
 	m := arg.NestedMap
 	if m == nil {
-		m = vm.Vars.Nest(arg.Name)
+		var err error
+		m, err = vm.Vars.TryNest(arg.Name)
+		if err != nil {
+			return ignoreUndefined(vm, err)
+		}
 	} else {
-		m2 := vm.Vars.Nest(m.nameInParent)
+		m2, err := vm.Vars.TryNest(m.nameInParent)
+		if err != nil {
+			return ignoreUndefined(vm, err)
+		}
 		if m2 != m {
 			return errors.New("cannot use a map that's not attached to this parent")
 		}
@@ -137,7 +149,7 @@ func bindSubsection(vm *ValueMap, arg BindArg) error {
 
 	var series ValueSeries
 	for _, row := range arg.NestedRows {
-		nestedVM := m.MustBind()
+		nestedVM := m.Rows().MustBind()
 		for _, col := range row {
 			if err := Bind(nestedVM, col); err != nil {
 				return err
@@ -150,15 +162,28 @@ func bindSubsection(vm *ValueMap, arg BindArg) error {
 
 func bindString(vm *ValueMap, arg BindArg) error {
 	v := arg.Var
+	var err error
 	if v == ZeroVar {
-		v = vm.Vars.Declare(arg.Name, arg.TrustRequirement)
+		v, err = vm.Vars.TryDeclare(arg.Name, arg.TrustRequirement)
 	} else {
-		v = vm.Vars.Attach(v, arg.TrustRequirement)
+		v, err = vm.Vars.TryAttach(v, arg.TrustRequirement)
+	}
+	if err != nil {
+		return ignoreUndefined(vm, err)
 	}
 
 	return vm.Set(v.Bind(arg.Value))
 }
 
+// ignoreUndefined drops ErrUndefined errors, unless the Map is Strict. This
+// matches the behavior of Map.Bind.
+func ignoreUndefined(vm *ValueMap, err error) error {
+	if !vm.Vars.Strict && errors.Is(err, ErrUndefined) {
+		return nil
+	}
+	return err
+}
+
 // BindArgs is a slice of BindArg values. Its only advantage over a plain slice
 // is that it knows how to DebugDump itself, and so can be passed to functions
 // that accept DebugDumper.
@@ -5,17 +5,27 @@ import (
 	"fmt"
 	"io"
 	"strings"
+	"time"
 )
 
 // ErrUndefined is returned when the ValueMap cannot set a value, because the
 // associated Var or nested Map aren't declared in ValueMap.Vars.
 var ErrUndefined = errors.New("undefined")
 
+// ErrWrongMap is returned when a row of a ValueStream isn't instantiated from
+// the Map it's bound to. (See Map.Rows.)
+var ErrWrongMap = errors.New("row from the wrong map")
+
+// ErrUnset is returned when a value is required, but the associated Var or
+// nested Map was never set on the ValueMap, and has no default.
+var ErrUnset = errors.New("unset")
+
 // Value specifies a single value for the ValueMap - it can be either a string
 // value of a Var, or a ValueStream value of a nested Map.
 type Value struct {
 	idx                    int
 	value                  string
+	typed                  interface{}
 	stream                 ValueStream
 	trustErr               error
 	debugOnlyName          string
@@ -35,13 +45,22 @@ type Value struct {
 // idiomatically - declare Maps on application startup, and then use the same
 // vars throughout the lifetime of the program.
 type ValueMap struct {
-	Vars    *Map
-	values  []string
+	Vars *Map
+	// Locale optionally names the locale of the values, such as "en-US". This
+	// package doesn't interpret it: it's used by the i18n package to select
+	// translations and formats. Nested ValueMaps don't inherit it.
+	Locale string
+
+	values []string
+	set    []bool
+	// The original values set by BindInt, BindTime, etc. Only allocated once
+	// the first such value is set.
+	typed   []interface{}
 	streams []ValueStream
 }
 
 func (vm *ValueMap) setNestedMapStream(v Value) error {
-	limit := len(vm.Vars.maps)
+	limit := vm.Vars.numMaps()
 	if limit <= v.idx {
 		return fmt.Errorf("%w subsection value stream %s", ErrUndefined, v.debugOnlyName)
 	}
@@ -56,7 +75,7 @@ func (vm *ValueMap) setNestedMapStream(v Value) error {
 }
 
 func (vm *ValueMap) setValue(v Value) error {
-	limit := len(vm.Vars.vars)
+	limit := vm.Vars.numVars()
 	if limit <= v.idx {
 		return fmt.Errorf("%w var %s", ErrUndefined, v.debugOnlyName)
 	}
@@ -64,9 +83,21 @@ func (vm *ValueMap) setValue(v Value) error {
 		tmp := vm.values
 		vm.values = make([]string, limit)
 		copy(vm.values, tmp)
+		tmpSet := vm.set
+		vm.set = make([]bool, limit)
+		copy(vm.set, tmpSet)
 	}
 
 	vm.values[v.idx] = v.value
+	vm.set[v.idx] = true
+	if v.typed != nil && len(vm.typed) < limit {
+		tmp := vm.typed
+		vm.typed = make([]interface{}, limit)
+		copy(vm.typed, tmp)
+	}
+	if len(vm.typed) > v.idx {
+		vm.typed[v.idx] = v.typed
+	}
 	return nil
 }
 
@@ -85,15 +116,57 @@ func (vm *ValueMap) Set(v Value) error {
 	return vm.setValue(v)
 }
 
+// CopyFrom makes vm a copy of src, reusing vm's storage. Nested ValueStreams
+// are shared, not copied. Setting values on vm afterwards doesn't affect src.
+func (vm *ValueMap) CopyFrom(src *ValueMap) {
+	vm.Vars = src.Vars
+	vm.Locale = src.Locale
+
+	// Size for every Var, so that setting more values on the copy doesn't
+	// reallocate.
+	n := src.Vars.numVars()
+	// The slices are grown separately, because Set only grows typed for
+	// typed values.
+	if cap(vm.values) < n {
+		vm.values = make([]string, n)
+	}
+	if cap(vm.set) < n {
+		vm.set = make([]bool, n)
+	}
+	if cap(vm.typed) < n {
+		vm.typed = make([]interface{}, n)
+	}
+	vm.values = vm.values[:n]
+	vm.set = vm.set[:n]
+	vm.typed = vm.typed[:n]
+	for i := copy(vm.values, src.values); i < n; i++ {
+		vm.values[i] = ""
+	}
+	for i := copy(vm.set, src.set); i < n; i++ {
+		vm.set[i] = false
+	}
+	for i := copy(vm.typed, src.typed); i < n; i++ {
+		vm.typed[i] = nil
+	}
+	vm.streams = append(vm.streams[:0], src.streams...)
+}
+
 // GetString returns the string value for the Var, which must be associated to
-// this ValueMap.Vars, otherwise GetString will panic.
+// this ValueMap.Vars, otherwise GetString will panic. If the Var was never set,
+// GetString returns its default (see Map.SetDefault).
 //
-// GetString doesn't distinguish between empty strings and missing values.
+// GetString doesn't distinguish between empty strings and missing values. Use
+// Lookup for that.
 func (vm *ValueMap) GetString(v Var) string {
-	if len(vm.values) <= v.idx {
-		return ""
-	}
+	s, _ := vm.Lookup(v)
+	return s
+}
 
+// Lookup returns the string value for the Var, which must be associated to this
+// ValueMap.Vars, otherwise Lookup will panic. If the Var was never set, Lookup
+// returns its default, if one was declared. The boolean result reports whether
+// either a value or a default was found.
+func (vm *ValueMap) Lookup(v Var) (string, bool) {
 	if v.checkOnlyAttachedMap == nil {
 		panic(fmt.Sprintf("%v is unattached (programmer error - free variables MUST be attached to a map)", v))
 	}
@@ -101,7 +174,101 @@ func (vm *ValueMap) GetString(v Var) string {
 		panic(fmt.Sprintf("%v is bound to the map %q, this ValueMap is instantiated from %q (programmer error - variable used in wrong context)", v, v.checkOnlyAttachedMap.DebugName(), vm.Vars.DebugName()))
 	}
 
-	return vm.values[v.idx]
+	if len(vm.set) <= v.idx || !vm.set[v.idx] {
+		return vm.Vars.defaultValue(v.idx)
+	}
+	return vm.values[v.idx], true
+}
+
+// getTyped returns the original value set by one of the typed binders, like
+// Var.BindInt, or nil.
+func (vm *ValueMap) getTyped(v Var) interface{} {
+	if _, ok := vm.Lookup(v); !ok || len(vm.typed) <= v.idx {
+		return nil
+	}
+	return vm.typed[v.idx]
+}
+
+// GetInt returns the integer set with Var.BindInt. The boolean result is false
+// if the Var was unset, or set to a different type of value.
+func (vm *ValueMap) GetInt(v Var) (int64, bool) {
+	i, ok := vm.getTyped(v).(int64)
+	return i, ok
+}
+
+// GetFloat returns the number set with Var.BindFloat. The boolean result is
+// false if the Var was unset, or set to a different type of value.
+func (vm *ValueMap) GetFloat(v Var) (float64, bool) {
+	f, ok := vm.getTyped(v).(float64)
+	return f, ok
+}
+
+// GetBool returns the boolean set with Var.BindBool. The boolean result is
+// false if the Var was unset, or set to a different type of value.
+func (vm *ValueMap) GetBool(v Var) (bool, bool) {
+	b, ok := vm.getTyped(v).(bool)
+	return b, ok
+}
+
+// GetTime returns the time set with Var.BindTime. The boolean result is false
+// if the Var was unset, or set to a different type of value.
+func (vm *ValueMap) GetTime(v Var) (time.Time, bool) {
+	t, ok := vm.getTyped(v).(time.Time)
+	return t, ok
+}
+
+// ValidationError is returned by ValueMap.Validate. It lists every Var and
+// nested Map that has no value.
+type ValidationError struct {
+	// Paths of the missing values, such as "title" or "comments[3].author".
+	// Rows of nested ValueStreams are numbered from zero.
+	Missing []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%v values: %s", ErrUnset, strings.Join(e.Missing, ", "))
+}
+
+// Unwrap returns ErrUnset.
+func (e *ValidationError) Unwrap() error {
+	return ErrUnset
+}
+
+// Validate checks that every Var declared in the Map has a value or a default,
+// and that every nested Map has a ValueStream. It walks every row of every
+// nested ValueStream, and returns a single *ValidationError listing all the
+// missing values, or nil if nothing is missing.
+func (vm *ValueMap) Validate() error {
+	var missing []string
+	vm.validate("", &missing)
+	if len(missing) != 0 {
+		return &ValidationError{Missing: missing}
+	}
+	return nil
+}
+
+func (vm *ValueMap) validate(prefix string, missing *[]string) {
+	vars, maps := vm.Vars.snapshot()
+	for _, v := range vars {
+		if _, ok := vm.Lookup(v); !ok {
+			*missing = append(*missing, prefix+v.name)
+		}
+	}
+
+	for _, nm := range maps {
+		stream := vm.GetStream(nm)
+		if stream == nil {
+			*missing = append(*missing, prefix+nm.nameInParent)
+			continue
+		}
+
+		next := stream.Stream()
+		i := 0
+		for row := next(); row != nil; row = next() {
+			row.validate(fmt.Sprintf("%s%s[%d].", prefix, nm.nameInParent, i), missing)
+			i++
+		}
+	}
 }
 
 // GetStream returns the ValueStream associated with the Map. The Map must be a
@@ -130,17 +297,18 @@ func (vm *ValueMap) DebugDump(w io.Writer, depth int) {
 	}
 	fmt.Fprint(w, "\n")
 
-	for i, v := range vm.Vars.vars {
-		fmt.Fprintf(w, "%s\tvar %d/%d: %q@%d (%v)\n", indent, i+1, len(vm.Vars.vars), v.name, v.idx, v.level)
-		if s := vm.GetString(v); s != "" {
+	vars, maps := vm.Vars.snapshot()
+	for i, v := range vars {
+		fmt.Fprintf(w, "%s\tvar %d/%d: %q@%d (%v)\n", indent, i+1, len(vars), v.name, v.idx, v.level)
+		if s, ok := vm.Lookup(v); ok {
 			fmt.Fprintf(w, "%s\t\tstring %q\n", indent, s)
 		} else {
 			fmt.Fprintf(w, "%s\t\t(empty)\n", indent)
 		}
 	}
 
-	for i, nm := range vm.Vars.maps {
-		fmt.Fprintf(w, "%s\tnested map %d/%d %q@%d:\n", indent, i+1, len(vm.Vars.maps), nm.nameInParent, nm.idxInParent)
+	for i, nm := range maps {
+		fmt.Fprintf(w, "%s\tnested map %d/%d %q@%d:\n", indent, i+1, len(maps), nm.nameInParent, nm.idxInParent)
 		stream := vm.GetStream(nm)
 		if stream == nil {
 			fmt.Fprintf(w, "%s\t\t(empty)\n", indent)
@@ -4,8 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/the80srobot/html5/safe"
 )
@@ -26,6 +29,16 @@ func (v Var) String() string {
 	return fmt.Sprintf("Var{%d, %q, %v}", v.idx, v.name, v.level)
 }
 
+// Name returns the name the Var was declared with.
+func (v Var) Name() string {
+	return v.name
+}
+
+// TrustLevel returns the trust level required of values bound to this Var.
+func (v Var) TrustLevel() safe.TrustLevel {
+	return v.level
+}
+
 // Check whether this Var's trust level can satisfy the required trust level.
 func (v Var) Check(required safe.TrustLevel) bool {
 	return v.level == required ||
@@ -66,6 +79,90 @@ func (v Var) Bind(ss safe.String) Value {
 	return value
 }
 
+// BindVar returns a Value created by binding the string value of src in vm to
+// this Var. The value of src was already checked when it was set on vm, so this
+// only verifies that src's trust level satisfies this Var's requirement.
+func (v Var) BindVar(vm *ValueMap, src Var) Value {
+	if !src.Check(v.level) {
+		return Value{
+			debugOnlyName: v.name,
+			trustErr:      fmt.Errorf("binding value %s from %s: %w", v.name, src.name, safe.ErrStringUntrusted),
+		}
+	}
+	return Value{debugOnlyName: v.name, idx: v.idx, value: vm.GetString(src), checkOnlyContainingMap: v.checkOnlyAttachedMap}
+}
+
+// BindInt returns a Value created by formatting the integer in base 10. The
+// ValueMap also keeps the integer itself, which ValueMap.GetInt returns.
+//
+// Typed values are trusted in text and attribute contexts. Vars used in URLs,
+// or in several contexts, require more trust, so their typed values are
+// checked. (See bindTyped.) Formatted numbers always pass the check.
+func (v Var) BindInt(i int64) Value {
+	return v.bindTyped(strconv.FormatInt(i, 10), i)
+}
+
+// BindFloat is like BindInt, but for floating point numbers. Prec controls the
+// number of digits after the decimal point. (-1 uses the smallest number of
+// digits necessary, see strconv.FormatFloat.)
+func (v Var) BindFloat(f float64, prec int) Value {
+	return v.bindTyped(strconv.FormatFloat(f, 'f', prec, 64), f)
+}
+
+// BindBool is like BindInt, but for booleans, which are formatted as "true" or
+// "false".
+func (v Var) BindBool(b bool) Value {
+	return v.bindTyped(strconv.FormatBool(b), b)
+}
+
+// BindTime returns a Value created by formatting the time with the layout (see
+// time.Time.Format). The ValueMap also keeps the time itself, which
+// ValueMap.GetTime returns.
+//
+// The formatted time is trusted like a number, unless the layout introduces
+// characters that aren't safe for the Var's trust level, in which case the
+// Value is rejected by ValueMap.Set.
+func (v Var) BindTime(t time.Time, layout string) Value {
+	return v.bindTyped(t.Format(layout), t)
+}
+
+// bindTyped returns a Value with the formatted string s, if it's safe for the
+// Var's trust level:
+//
+// In text and attribute contexts, s must not have characters with a special
+// meaning in HTML (<>&'"). Vars that require URLSafe or FullyTrusted values
+// only accept letters, digits, spaces and ".,+-_", which can't form a URL
+// scheme, a path or a script.
+func (v Var) bindTyped(s string, typed interface{}) Value {
+	var ok bool
+	switch v.level {
+	case safe.URLSafe, safe.FullyTrusted:
+		ok = inert(s)
+	default:
+		ok = !strings.ContainsAny(s, "<>&'\"")
+	}
+	if !ok {
+		return Value{
+			debugOnlyName: v.name,
+			trustErr:      fmt.Errorf("binding value %s: formatted value %q: %w %v", v.name, s, safe.ErrStringUntrusted, v.level),
+		}
+	}
+	return Value{debugOnlyName: v.name, idx: v.idx, value: s, typed: typed, checkOnlyContainingMap: v.checkOnlyAttachedMap}
+}
+
+// inert returns whether s only has letters, digits, spaces and ".,+-_".
+func inert(s string) bool {
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		case strings.ContainsRune(" .,+-_", r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
 // Attached returns whether this Var is attached to a Map.
 func (v Var) Attached() bool {
 	return v.checkOnlyAttachedMap != nil
@@ -79,12 +176,35 @@ func (v Var) BindConst(value constString) Value {
 	return Value{debugOnlyName: v.name, idx: v.idx, value: string(value), checkOnlyContainingMap: v.checkOnlyAttachedMap}
 }
 
+// ErrFrozen is returned when a Var or nested Map cannot be declared, because
+// the Map is frozen. (Check with errors.Is, not with ==.)
+var ErrFrozen = errors.New("map is frozen")
+
+// frozenError reports a declaration on a frozen Map. If the declaration was
+// of a new Var or nested Map, it also matches ErrUndefined.
+type frozenError struct {
+	what      string
+	undefined bool
+}
+
+func (e *frozenError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrFrozen, e.what)
+}
+
+func (e *frozenError) Is(target error) bool {
+	return target == ErrFrozen || (e.undefined && target == ErrUndefined)
+}
+
 // Map is a collection of Vars and nested Maps. Each html5.Template uses a
 // single root Map to hold all the dynamic elements of the page, and their
 // requisite levels of trust.
 //
 // Maps are "instantiated" into ValueMaps, which specify a set of values for the
 // Vars and nested Maps in the Map.
+//
+// Maps are safe for concurrent use. Once a Map is frozen (html5.Compile freezes
+// the Map it's given), it becomes immutable: reads no longer need to take a
+// lock, and declaring new Vars or nested Maps is an error.
 type Map struct {
 	// If true, then the Map won't accept Bind arguments for non-existent Vars.
 	Strict bool
@@ -95,11 +215,64 @@ type Map struct {
 	varsByName   map[string]int
 	maps         []*Map
 	mapsByName   map[string]int
-
-	disallowCopy       sync.Mutex
+	defaults     map[int]safe.String
+	// If set, rows of this nested Map's ValueStreams are instantiated from
+	// rows, instead of this Map. (See TryNestRows.)
+	rows *Map
+
+	// Guards vars, varsByName, maps and mapsByName, until the Map is frozen.
+	// Also prevents copying the Map (go vet will complain).
+	mu                 sync.RWMutex
+	frozen             int32
 	checkOnlyParentMap *Map
 }
 
+// Freeze makes this Map and all its nested Maps immutable. Declaring Vars and
+// nested Maps that already exist still works, but declaring new ones (or
+// raising the trust level of existing ones) fails with ErrFrozen.
+func (m *Map) Freeze() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, nm := range m.maps {
+		nm.Freeze()
+	}
+	atomic.StoreInt32(&m.frozen, 1)
+}
+
+// Frozen returns whether the Map was frozen.
+func (m *Map) Frozen() bool {
+	return atomic.LoadInt32(&m.frozen) != 0
+}
+
+func (m *Map) numVars() int {
+	if m.Frozen() {
+		return len(m.vars)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.vars)
+}
+
+func (m *Map) numMaps() int {
+	if m.Frozen() {
+		return len(m.maps)
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.maps)
+}
+
+// snapshot returns the Map's Vars and nested Maps. The caller must not modify
+// the returned slices.
+func (m *Map) snapshot() ([]Var, []*Map) {
+	if m.Frozen() {
+		return m.vars, m.maps
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.vars[:len(m.vars):len(m.vars)], m.maps[:len(m.maps):len(m.maps)]
+}
+
 // Root returns whether this Map is the top-most Map in the hierarchy, or a
 // nested Map.
 func (m *Map) Root() bool {
@@ -112,16 +285,42 @@ func (m *Map) String() string {
 	return sb.String()
 }
 
-// Declare a Var with the given name, at the given trust level.
+// Declare a Var with the given name, at the given trust level. Declare panics if
+// the Map is frozen and the declaration would change it. (Use TryDeclare to get
+// an error instead.)
 func (m *Map) Declare(name string, level safe.TrustLevel) Var {
+	v, err := m.TryDeclare(name, level)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// TryDeclare is like Declare, but returns an error wrapping ErrFrozen if the Map
+// is frozen and doesn't already have a suitable Var.
+func (m *Map) TryDeclare(name string, level safe.TrustLevel) (Var, error) {
 	if name == "" {
 		panic("Var name cannot be empty")
 	}
 
+	if m.Frozen() {
+		return m.lookupFrozen(name, level)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Frozen() {
+		return m.lookupFrozen(name, level)
+	}
+
 	idx, ok := m.varsByName[name]
 	if ok {
 		m.vars[idx].level = safe.Max(m.vars[idx].level, level)
-		return m.vars[idx]
+		// The default may no longer be trusted enough.
+		if d, ok := m.defaults[idx]; ok && !d.Check(m.vars[idx].level) {
+			delete(m.defaults, idx)
+		}
+		return m.vars[idx], nil
 	}
 
 	idx = len(m.vars)
@@ -131,7 +330,73 @@ func (m *Map) Declare(name string, level safe.TrustLevel) Var {
 	} else {
 		m.varsByName[name] = idx
 	}
-	return m.vars[idx]
+	return m.vars[idx], nil
+}
+
+func (m *Map) lookupFrozen(name string, level safe.TrustLevel) (Var, error) {
+	idx, ok := m.varsByName[name]
+	if !ok {
+		return ZeroVar, &frozenError{what: fmt.Sprintf("cannot declare var %s", name), undefined: true}
+	}
+	v := m.vars[idx]
+	if safe.Max(v.level, level) != v.level {
+		return ZeroVar, &frozenError{what: fmt.Sprintf("cannot raise trust level of var %s from %v to %v", name, v.level, level)}
+	}
+	return v, nil
+}
+
+// SetDefault declares a default value for the Var, which must be attached to this
+// Map. ValueMaps return the default for the Var until another value is set.
+//
+// The default must satisfy the Var's trust level. SetDefault fails with
+// ErrFrozen if the Map is frozen.
+func (m *Map) SetDefault(v Var, s safe.String) error {
+	if v.checkOnlyAttachedMap != m {
+		panic(fmt.Sprintf("%v is bound to the map %q, not %q (programmer error - variable used in wrong context)", v, v.checkOnlyAttachedMap.DebugName(), m.DebugName()))
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Frozen() {
+		return &frozenError{what: fmt.Sprintf("cannot set default of var %s", v.name)}
+	}
+
+	// Check against the current trust level, in case it was raised since v was
+	// declared.
+	if _, err := m.vars[v.idx].tryBind(s); err != nil {
+		return err
+	}
+	if m.defaults == nil {
+		m.defaults = map[int]safe.String{v.idx: s}
+	} else {
+		m.defaults[v.idx] = s
+	}
+	return nil
+}
+
+func (m *Map) defaultValue(idx int) (string, bool) {
+	if !m.Frozen() {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+	}
+	s, ok := m.defaults[idx]
+	if !ok {
+		return "", false
+	}
+	return s.String(), true
+}
+
+// Lookup returns the Var with the given name, if one was declared.
+func (m *Map) Lookup(name string) (Var, bool) {
+	if !m.Frozen() {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+	}
+	idx, ok := m.varsByName[name]
+	if !ok {
+		return ZeroVar, false
+	}
+	return m.vars[idx], true
 }
 
 // Attach returns a copy of the provided free Var that's associated to this Map.
@@ -139,14 +404,41 @@ func (m *Map) Attach(v Var, level safe.TrustLevel) Var {
 	return m.Declare(v.name, safe.Max(v.level, level))
 }
 
+// TryAttach is like Attach, but returns an error instead of panicking if the Map
+// is frozen.
+func (m *Map) TryAttach(v Var, level safe.TrustLevel) (Var, error) {
+	return m.TryDeclare(v.name, safe.Max(v.level, level))
+}
+
 // Nest creates a nested Map with the given name and returns it. Nested Maps can
 // be used to create ValueStreams, which specify repeated sections in the HTML
 // page. (For example, comments under an article.) Maps can be nested to
 // arbitrary depth.
+//
+// Nest panics if the Map is frozen and has no nested Map with the given name.
+// (Use TryNest to get an error instead.)
 func (m *Map) Nest(name string) *Map {
+	nm, err := m.TryNest(name)
+	if err != nil {
+		panic(err)
+	}
+	return nm
+}
+
+// TryNest is like Nest, but returns an error wrapping ErrFrozen if the Map is
+// frozen and has no nested Map with the given name.
+func (m *Map) TryNest(name string) (*Map, error) {
+	if !m.Frozen() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+	}
+
 	idx, ok := m.mapsByName[name]
 	if ok {
-		return m.maps[idx]
+		return m.maps[idx], nil
+	}
+	if m.Frozen() {
+		return nil, &frozenError{what: fmt.Sprintf("cannot declare nested map %s", name), undefined: true}
 	}
 
 	idx = len(m.maps)
@@ -156,7 +448,46 @@ func (m *Map) Nest(name string) *Map {
 	} else {
 		m.mapsByName[name] = idx
 	}
-	return m.maps[idx]
+	return m.maps[idx], nil
+}
+
+// TryNestRows is like TryNest, but the rows of the nested Map's ValueStreams
+// are instantiated from the Map rows, not from the nested Map. This is for
+// repeating a part of the page that has its own Map, such as a precompiled
+// template.
+//
+// It fails if the nested Map already exists with different rows, unless it's
+// still empty and the Map isn't frozen.
+func (m *Map) TryNestRows(name string, rows *Map) (*Map, error) {
+	nm, err := m.TryNest(name)
+	if err != nil {
+		return nil, err
+	}
+	if nm.Rows() == rows {
+		return nm, nil
+	}
+
+	nm.mu.Lock()
+	defer nm.mu.Unlock()
+	if nm.Frozen() || nm.rows != nil || len(nm.vars) != 0 || len(nm.maps) != 0 {
+		return nil, fmt.Errorf("nested map %s already has rows instantiated from another map, cannot use %s", name, rows.DebugName())
+	}
+	nm.rows = rows
+	return nm, nil
+}
+
+// Rows returns the Map that the rows of this Map's ValueStreams are
+// instantiated from. That's the Map itself, unless it was nested with
+// TryNestRows.
+func (m *Map) Rows() *Map {
+	if !m.Frozen() {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+	}
+	if m.rows != nil {
+		return m.rows
+	}
+	return m
 }
 
 // Bind creates a ValueMap and sets the provided values, if any. The Values must
@@ -189,8 +520,15 @@ func (m *Map) MustBind(values ...Value) *ValueMap {
 }
 
 // BindStream binds the provided ValueStream to this Map, returning a Value that
-// can be set on a parent ValueMap.
+// can be set on a parent ValueMap. The rows must be instantiated from Rows().
+// For a ValueSeries, this is checked now, and ValueMap.Set rejects the Value if
+// a row is from another Map.
 func (m *Map) BindStream(stream ValueStream) Value {
+	if series, ok := stream.(ValueSeries); ok {
+		if err := m.checkRows(series); err != nil {
+			return Value{debugOnlyName: m.nameInParent, trustErr: err}
+		}
+	}
 	return Value{
 		idx:                    m.idxInParent,
 		stream:                 stream,
@@ -199,6 +537,18 @@ func (m *Map) BindStream(stream ValueStream) Value {
 	}
 }
 
+// checkRows returns an error if any of the rows isn't instantiated from
+// Rows().
+func (m *Map) checkRows(series ValueSeries) error {
+	rows := m.Rows()
+	for i, row := range series {
+		if row.Vars != rows {
+			return fmt.Errorf("%w: row %d of %s is instantiated from %s, wanted %s", ErrWrongMap, i, m.DebugName(), row.Vars.DebugName(), rows.DebugName())
+		}
+	}
+	return nil
+}
+
 // BindSeries is like BindStream, but constructs a ValueStream for the caller.
 func (m *Map) BindSeries(maps ...*ValueMap) Value {
 	return m.BindStream(ValueSeries(maps))
@@ -227,12 +577,13 @@ func (m *Map) DebugDump(w io.Writer, depth int) {
 	}
 	fmt.Fprint(w, "\n")
 
-	for i, v := range m.vars {
-		fmt.Fprintf(w, "%s\tvar %d/%d: %q@%d (%v)\n", indent, i+1, len(m.vars), v.name, v.idx, v.level)
+	vars, maps := m.snapshot()
+	for i, v := range vars {
+		fmt.Fprintf(w, "%s\tvar %d/%d: %q@%d (%v)\n", indent, i+1, len(vars), v.name, v.idx, v.level)
 	}
 
-	for i, nm := range m.maps {
-		fmt.Fprintf(w, "%s\tnested map %d/%d:\n", indent, i+1, len(m.maps))
+	for i, nm := range maps {
+		fmt.Fprintf(w, "%s\tnested map %d/%d:\n", indent, i+1, len(maps))
 		nm.DebugDump(w, depth+1)
 	}
 
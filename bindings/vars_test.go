@@ -1,8 +1,13 @@
 package bindings
 
 import (
+	"errors"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/google/go-cmp/cmp"
 	"github.com/the80srobot/html5/safe"
 )
 
@@ -162,6 +167,25 @@ func TestNestedMapWrongBindingGet(t *testing.T) {
 	articleValues.GetString(pageTitle)
 }
 
+func TestLookupWrongMapUnset(t *testing.T) {
+	var page Map
+	pageTitle := page.Declare("title", safe.TextSafe)
+	articleBindings := page.Nest("articles")
+	articleTitle := articleBindings.Declare("title", safe.TextSafe)
+	if err := articleBindings.SetDefault(articleTitle, safe.Const("Untitled")); err != nil {
+		t.Fatalf("SetDefault: %v", err)
+	}
+
+	// Even though the Var is unset, and the Map has a default at the same
+	// index, using the wrong Var is still a programmer error.
+	defer func() {
+		if r := recover(); r == nil {
+			t.Errorf("expected Lookup(%v) to panic", pageTitle)
+		}
+	}()
+	articleBindings.MustBind().Lookup(pageTitle)
+}
+
 func TestNestedMapWrongBindingSet(t *testing.T) {
 	var page Map
 	page.Declare("title", safe.HTMLSafe)
@@ -200,6 +224,79 @@ func TestVarAttach(t *testing.T) {
 	}
 }
 
+func TestValueMapCopyFrom(t *testing.T) {
+	var m Map
+	title := m.Declare("title", safe.Default)
+	count := m.Declare("count", safe.Default)
+	comments := m.Nest("comments")
+	src := m.MustBind(title.BindConst("Hello"), comments.BindSeries(comments.MustBind()))
+
+	var vm ValueMap
+	for i := 0; i < 2; i++ {
+		vm.CopyFrom(src)
+		if s := vm.GetString(title); s != "Hello" {
+			t.Errorf("GetString(%v) of the copy => %q, wanted %q", title, s, "Hello")
+		}
+		if _, ok := vm.GetInt(count); ok {
+			t.Errorf("GetInt(%v) of the copy => ok, wanted unset", count)
+		}
+		if vm.GetStream(comments) == nil {
+			t.Errorf("GetStream(%v) of the copy => nil", comments.DebugName())
+		}
+		if err := vm.Set(count.BindInt(int64(i))); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := src.GetInt(count); ok {
+			t.Errorf("Setting %v on the copy also set it on the original", count)
+		}
+	}
+
+	// The destination already has values, but no typed ones.
+	typedSrc := m.MustBind(count.BindInt(3))
+	bound := m.MustBind(title.BindConst("Stale"))
+	bound.CopyFrom(typedSrc)
+	if _, ok := bound.Lookup(title); ok {
+		t.Errorf("Lookup(%v) of a bound copy => ok, wanted unset", title)
+	}
+	if i, ok := bound.GetInt(count); !ok || i != 3 {
+		t.Errorf("GetInt(%v) of a bound copy => (%d, %v), wanted (3, true)", count, i, ok)
+	}
+}
+
+func TestTryNestRows(t *testing.T) {
+	var widget Map
+	user := widget.Declare("user", safe.Default)
+
+	var page Map
+	likes, err := page.TryNestRows("likes", &widget)
+	if err != nil {
+		t.Fatalf("TryNestRows: %v", err)
+	}
+	if likes.Rows() != &widget {
+		t.Errorf("Rows() => %s, wanted the widget Map", likes.Rows().DebugName())
+	}
+	if again, err := page.TryNestRows("likes", &widget); err != nil || again != likes {
+		t.Errorf("TryNestRows again => %v, %v, wanted the same nested Map", again, err)
+	}
+	if _, err := page.TryNestRows("likes", &page); err == nil {
+		t.Error("TryNestRows with different rows => no error")
+	}
+
+	vm := page.MustBind()
+	if err := vm.Set(likes.BindSeries(widget.MustBind(user.BindConst("John")))); err != nil {
+		t.Errorf("Set(BindSeries) of widget rows: %v", err)
+	}
+	if err := vm.Set(likes.BindSeries(likes.MustBind())); !errors.Is(err, ErrWrongMap) {
+		t.Errorf("Set(BindSeries) of a row from %s => %v, wanted %v", likes.DebugName(), err, ErrWrongMap)
+	}
+	if err := Bind(vm, BindArg{Name: "likes", NestedRows: [][]BindArg{{{Name: "user", Value: safe.Const("Jane")}}}}); err != nil {
+		t.Errorf("Bind with NestedRows: %v", err)
+	}
+	if got := vm.GetStream(likes).Stream()().GetString(user); got != "Jane" {
+		t.Errorf("GetString(%v) of the bound row => %q, wanted %q", user, got, "Jane")
+	}
+}
+
 func TestTrustClimbing(t *testing.T) {
 	var m Map
 	v := m.Declare("comment_text", safe.TextSafe)
@@ -226,3 +323,230 @@ func TestTrustClimbing(t *testing.T) {
 		t.Errorf("Var.Set() of a FullyTrusted string: %v", err)
 	}
 }
+
+// Declares and binds the same names from many goroutines. Run under the race
+// detector (go test -race) to check that this is safe.
+func TestConcurrentDeclare(t *testing.T) {
+	var m Map
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				m.Declare(fmt.Sprintf("var_%d", j), safe.Default)
+				m.Nest(fmt.Sprintf("map_%d", j)).Declare("nested", safe.Default)
+				vm := m.MustBind()
+				if err := Bind(vm, BindArg{Name: fmt.Sprintf("bound_%d", i), Value: safe.Const("value")}); err != nil {
+					t.Errorf("Bind: %v", err)
+				}
+				GetStringByName(vm, "var_0")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, ok := m.Lookup("var_19"); !ok {
+		t.Errorf("Lookup(%q) found nothing after concurrent declarations", "var_19")
+	}
+	if _, ok := m.Lookup("bound_7"); !ok {
+		t.Errorf("Lookup(%q) found nothing after concurrent Bind calls", "bound_7")
+	}
+}
+
+func TestFrozenMap(t *testing.T) {
+	var m Map
+	title := m.Declare("title", safe.TextSafe)
+	comments := m.Nest("comments")
+	comments.Declare("author", safe.TextSafe)
+	m.Freeze()
+
+	if !comments.Frozen() {
+		t.Error("Freeze() didn't freeze the nested Map")
+	}
+	if v, err := m.TryDeclare("title", safe.Default); err != nil || v != title {
+		t.Errorf("TryDeclare(%q) of an existing Var on a frozen Map => (%v, %v), wanted (%v, nil)", "title", v, err, title)
+	}
+	if _, err := m.TryDeclare("body", safe.Default); !errors.Is(err, ErrFrozen) {
+		t.Errorf("TryDeclare(%q) of a new Var on a frozen Map => %v, wanted %v", "body", err, ErrFrozen)
+	}
+	if _, err := m.TryDeclare("title", safe.URLSafe); !errors.Is(err, ErrFrozen) {
+		t.Errorf("TryDeclare(%q) raising the trust level on a frozen Map => %v, wanted %v", "title", err, ErrFrozen)
+	}
+	if _, err := comments.TryNest("replies"); !errors.Is(err, ErrFrozen) {
+		t.Errorf("TryNest(%q) on a frozen Map => %v, wanted %v", "replies", err, ErrFrozen)
+	}
+
+	// Late declarations through Bind are ignored, unless the Map is Strict.
+	vm := m.MustBind()
+	if err := Bind(vm, BindArg{Name: "body", Value: safe.Const("Hello")}); err != nil {
+		t.Errorf("Bind of an undeclared name on a frozen Map: %v", err)
+	}
+	m.Strict = true
+	if err := Bind(vm, BindArg{Name: "body", Value: safe.Const("Hello")}); !errors.Is(err, ErrUndefined) {
+		t.Errorf("Bind of an undeclared name on a frozen, Strict Map => %v, wanted %v", err, ErrUndefined)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	var m Map
+	author := m.Declare("author", safe.TextSafe)
+	title := m.Declare("title", safe.TextSafe)
+	category := m.Declare("category", safe.TextSafe)
+	if err := m.SetDefault(category, safe.Const("Uncategorized")); err != nil {
+		t.Fatalf("SetDefault: %v", err)
+	}
+	if err := m.SetDefault(title, safe.UntrustedString("<b>")); !errors.Is(err, safe.ErrStringUntrusted) {
+		t.Errorf("SetDefault of an untrusted string on a TextSafe Var => %v, wanted %v", err, safe.ErrStringUntrusted)
+	}
+
+	vm := m.MustBind(title.BindConst(""))
+	for _, tc := range []struct {
+		v      Var
+		want   string
+		wantOK bool
+	}{
+		{v: author, want: "", wantOK: false},
+		{v: title, want: "", wantOK: true},
+		{v: category, want: "Uncategorized", wantOK: true},
+	} {
+		if s, ok := vm.Lookup(tc.v); s != tc.want || ok != tc.wantOK {
+			t.Errorf("Lookup(%v) => (%q, %v), wanted (%q, %v)", tc.v, s, ok, tc.want, tc.wantOK)
+		}
+	}
+
+	vm.Set(category.BindConst("Diary"))
+	if s := vm.GetString(category); s != "Diary" {
+		t.Errorf("GetString(%v) => %q, wanted the set value %q instead of the default", category, s, "Diary")
+	}
+
+	m.Freeze()
+	if err := m.SetDefault(author, safe.Const("Anonymous")); !errors.Is(err, ErrFrozen) {
+		t.Errorf("SetDefault on a frozen Map => %v, wanted %v", err, ErrFrozen)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var m Map
+	title := m.Declare("title", safe.TextSafe)
+	comments := m.Nest("comments")
+	author := comments.Declare("author", safe.TextSafe)
+	text := comments.Declare("text", safe.TextSafe)
+	tags := m.Nest("tags")
+	tags.Declare("tag", safe.TextSafe)
+
+	vm := m.MustBind(comments.BindSeries(
+		comments.MustBind(author.BindConst("Bob"), text.BindConst("Hi!")),
+		comments.MustBind(text.BindConst("Hello!")),
+		comments.MustBind()))
+
+	err := vm.Validate()
+	if !errors.Is(err, ErrUnset) {
+		t.Fatalf("Validate() => %v, wanted %v", err, ErrUnset)
+	}
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Validate() => %v, wanted a *ValidationError", err)
+	}
+	want := []string{"title", "comments[1].author", "comments[2].author", "comments[2].text", "tags"}
+	if diff := cmp.Diff(want, verr.Missing); diff != "" {
+		t.Errorf("Validate() missing values => (-)wanted vs (+)got:\n%s", diff)
+	}
+
+	vm.Set(title.BindConst("Articles"))
+	vm.Set(tags.BindSeries())
+	vm.Set(comments.BindSeries(comments.MustBind(author.BindConst("Bob"), text.BindConst("Hi!"))))
+	if err := vm.Validate(); err != nil {
+		t.Errorf("Validate() of a complete ValueMap: %v", err)
+	}
+}
+
+func TestTypedValues(t *testing.T) {
+	var m Map
+	count := m.Declare("count", safe.URLSafe)
+	price := m.Declare("price", safe.AttributeSafe)
+	published := m.Declare("published", safe.TextSafe)
+	visible := m.Declare("visible", safe.FullyTrusted)
+
+	date := time.Date(2021, time.January, 2, 15, 4, 5, 0, time.UTC)
+	vm, err := m.Bind(
+		count.BindInt(-42),
+		price.BindFloat(9.5, 2),
+		published.BindTime(date, "2006-01-02"),
+		visible.BindBool(true))
+	if err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+
+	for _, tc := range []struct {
+		v    Var
+		want string
+	}{
+		{v: count, want: "-42"},
+		{v: price, want: "9.50"},
+		{v: published, want: "2021-01-02"},
+		{v: visible, want: "true"},
+	} {
+		if s := vm.GetString(tc.v); s != tc.want {
+			t.Errorf("GetString(%v) => %q, wanted %q", tc.v, s, tc.want)
+		}
+	}
+
+	if i, ok := vm.GetInt(count); !ok || i != -42 {
+		t.Errorf("GetInt(%v) => (%v, %v), wanted (-42, true)", count, i, ok)
+	}
+	if f, ok := vm.GetFloat(price); !ok || f != 9.5 {
+		t.Errorf("GetFloat(%v) => (%v, %v), wanted (9.5, true)", price, f, ok)
+	}
+	if d, ok := vm.GetTime(published); !ok || !d.Equal(date) {
+		t.Errorf("GetTime(%v) => (%v, %v), wanted (%v, true)", published, d, ok, date)
+	}
+	if b, ok := vm.GetBool(visible); !ok || !b {
+		t.Errorf("GetBool(%v) => (%v, %v), wanted (true, true)", visible, b, ok)
+	}
+	if _, ok := vm.GetInt(price); ok {
+		t.Errorf("GetInt(%v) of a float value should fail", price)
+	}
+
+	// Overwriting with a string drops the typed value.
+	vm.Set(count.BindConst("many"))
+	if _, ok := vm.GetInt(count); ok {
+		t.Errorf("GetInt(%v) after setting a string should fail", count)
+	}
+
+	if err := vm.Set(published.BindTime(date, "<b>2006</b>")); !errors.Is(err, safe.ErrStringUntrusted) {
+		t.Errorf("Set() of a time formatted with HTML => %v, wanted %v", err, safe.ErrStringUntrusted)
+	}
+}
+
+func TestTypedValuesTrust(t *testing.T) {
+	var m Map
+	link := m.Declare("link", safe.URLSafe)
+	value := m.Declare("value", safe.FullyTrusted)
+	class := m.Declare("class", safe.AttributeSafe)
+	date := time.Date(2021, time.March, 4, 15, 4, 5, 0, time.UTC)
+
+	for _, tc := range []struct {
+		comment string
+		value   Value
+		wantErr bool
+	}{
+		{comment: "int in a URL", value: link.BindInt(-42)},
+		{comment: "float in a URL", value: link.BindFloat(9.5, 2)},
+		{comment: "bool in a fully trusted Var", value: value.BindBool(true)},
+		{comment: "date in a URL", value: link.BindTime(date, "2006-01-02")},
+		{comment: "script in a URL", value: link.BindTime(date, "javascript:alert(1)"), wantErr: true},
+		{comment: "path in a URL", value: link.BindTime(date, "//example.com/2006"), wantErr: true},
+		{comment: "script in a fully trusted Var", value: value.BindTime(date, "javascript:alert(1)"), wantErr: true},
+		{comment: "clock in an attribute", value: class.BindTime(date, "15:04")},
+		{comment: "HTML in an attribute", value: class.BindTime(date, "<b>2006</b>"), wantErr: true},
+	} {
+		err := m.MustBind().Set(tc.value)
+		if tc.wantErr && !errors.Is(err, safe.ErrStringUntrusted) {
+			t.Errorf("%s: Set() => %v, wanted %v", tc.comment, err, safe.ErrStringUntrusted)
+		}
+		if !tc.wantErr && err != nil {
+			t.Errorf("%s: Set() => %v, wanted no error", tc.comment, err)
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package html5
+
+import (
+	"io"
+	"net"
+
+	"github.com/the80srobot/html5/bindings"
+)
+
+// Page is a Template bound to a ValueMap and RenderOptions. It implements
+// io.WriterTo, so it can be written with io.Copy.
+type Page struct {
+	Template *Template
+	Values   *bindings.ValueMap
+	Options  *RenderOptions
+}
+
+// Page returns the Template bound to the ValueMap and RenderOptions.
+func (t *Template) Page(vm *bindings.ValueMap, opts *RenderOptions) *Page {
+	return &Page{Template: t, Values: vm, Options: opts}
+}
+
+// WriteTo writes the page to w. The output is gathered with Buffers, and
+// written with net.Buffers.WriteTo, which is a single vectored write if w is a
+// net.Conn. Templates with FlushPoints or deferred content are streamed
+// instead, like with Render, because gathering would hold back the output
+// until the whole page is ready.
+func (p *Page) WriteTo(w io.Writer) (int64, error) {
+	if p.Template.flushes || p.Template.deferred {
+		sw := streamWriter{w: w}
+		err := p.Template.Render(&sw, p.Values, p.Options)
+		return sw.n, err
+	}
+	bufs, err := p.Template.Buffers(p.Values, p.Options)
+	if err != nil {
+		return 0, err
+	}
+	return bufs.WriteTo(w)
+}
+
+// streamWriter counts the bytes written to w, and passes flushes on to it.
+type streamWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (sw *streamWriter) Write(p []byte) (int, error) {
+	n, err := sw.w.Write(p)
+	sw.n += int64(n)
+	return n, err
+}
+
+func (sw *streamWriter) WriteString(s string) (int, error) {
+	n, err := io.WriteString(sw.w, s)
+	sw.n += int64(n)
+	return n, err
+}
+
+func (sw *streamWriter) Flush() error {
+	switch w := sw.w.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case interface{ Flush() }:
+		w.Flush()
+	}
+	return nil
+}
+
+// Buffers renders the Template into a list of byte slices, suitable for a
+// vectored write with net.Buffers.WriteTo. Static HTML isn't copied: the
+// slices share memory with the Template, and must not be modified.
+//
+// Nothing is returned until the whole page is rendered, so FlushPoints are
+// ignored, and deferred content is waited for. Page.WriteTo only uses Buffers
+// for Templates without either.
+func (t *Template) Buffers(vm *bindings.ValueMap, opts *RenderOptions) (net.Buffers, error) {
+	if opts.Validate {
+		if err := vm.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return t.gather(vm, t.newRenderContext(vm, opts))
+}
+
+func (t *Template) gather(vm *bindings.ValueMap, rc renderContext) (net.Buffers, error) {
+	var g gatherWriter
+	if err := t.renderPage(&g, vm, rc); err != nil {
+		return nil, err
+	}
+	return g.bufs, nil
+}
+
+// Minimum size of the gatherWriter's scratch arrays.
+const minScratchSize = 512
+
+// gatherWriter collects the output of a Template as a list of byte slices.
+// Static chunks are appended as is, while everything else is copied into
+// scratch arrays, with consecutive writes coalesced into one slice.
+type gatherWriter struct {
+	bufs    net.Buffers
+	scratch []byte
+	// If open, then the last slice in bufs is scratch[start:], and can be
+	// extended.
+	open  bool
+	start int
+}
+
+func (g *gatherWriter) writeStatic(p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	g.bufs = append(g.bufs, p)
+	g.open = false
+}
+
+// extend makes room for n more bytes in the scratch array, and returns them.
+func (g *gatherWriter) extend(n int) []byte {
+	if cap(g.scratch)-len(g.scratch) < n {
+		// Earlier slices keep the old array alive, so it's not copied.
+		size := 2 * cap(g.scratch)
+		if size < minScratchSize {
+			size = minScratchSize
+		}
+		if size < n {
+			size = n
+		}
+		g.scratch = make([]byte, 0, size)
+		g.open = false
+	}
+
+	end := len(g.scratch)
+	g.scratch = g.scratch[:end+n]
+	if g.open {
+		g.bufs[len(g.bufs)-1] = g.scratch[g.start:]
+	} else {
+		g.bufs = append(g.bufs, g.scratch[end:])
+		g.start = end
+		g.open = true
+	}
+	return g.scratch[end:]
+}
+
+func (g *gatherWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	return copy(g.extend(len(p)), p), nil
+}
+
+func (g *gatherWriter) WriteString(s string) (int, error) {
+	if len(s) == 0 {
+		return 0, nil
+	}
+	return copy(g.extend(len(s)), s), nil
+}
@@ -0,0 +1,162 @@
+package html5
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestGatherWriter(t *testing.T) {
+	var g gatherWriter
+	static := []byte("<static>")
+	g.WriteString("a")
+	g.Write([]byte("b"))
+	g.writeStatic(static)
+	g.writeStatic(nil)
+	g.WriteString("")
+	g.WriteString(strings.Repeat("c", minScratchSize))
+	g.WriteString("d")
+
+	var got []string
+	for _, b := range g.bufs {
+		got = append(got, string(b))
+	}
+	// The c's don't fit in the first scratch array, but the d is still
+	// coalesced with them in the second.
+	want := []string{"ab", "<static>", strings.Repeat("c", minScratchSize) + "d"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("gatherWriter buffers => (-)wanted vs (+)got:\n%s", diff)
+	}
+	if &g.bufs[1][0] != &static[0] {
+		t.Error("gatherWriter copied static data")
+	}
+}
+
+func TestBuffers(t *testing.T) {
+	for _, opts := range []*CompileOptions{&Compact, &Tidy, &Debug} {
+		tmpl, vm := benchmarkPage(t, opts)
+
+		var want bytes.Buffer
+		if err := tmpl.GenerateHTML(&want, vm); err != nil {
+			t.Fatalf("GenerateHTML: %v", err)
+		}
+
+		bufs, err := tmpl.Buffers(vm, &RenderOptions{})
+		if err != nil {
+			t.Fatalf("Buffers: %v", err)
+		}
+		if diff := cmp.Diff(want.String(), string(bytes.Join(bufs, nil))); diff != "" {
+			t.Errorf("Buffers() with options %v => (-)wanted vs (+)got:\n%s", opts, diff)
+		}
+
+		// Page gathers the output, and writes it all at once.
+		client, server := net.Pipe()
+		done := make(chan error)
+		go func() {
+			n, err := tmpl.Page(vm, &RenderOptions{}).WriteTo(server)
+			if err == nil && n != int64(want.Len()) {
+				t.Errorf("Page.WriteTo() => %d bytes, wanted %d", n, want.Len())
+			}
+			server.Close()
+			done <- err
+		}()
+		got, err := ioutil.ReadAll(client)
+		if err != nil {
+			t.Fatalf("ReadAll: %v", err)
+		}
+		if err := <-done; err != nil {
+			t.Fatalf("Page.WriteTo(net.Conn): %v", err)
+		}
+		if diff := cmp.Diff(want.String(), string(got)); diff != "" {
+			t.Errorf("Page.WriteTo(net.Conn) with options %v => (-)wanted vs (+)got:\n%s", opts, diff)
+		}
+	}
+}
+
+func TestPageStreamsFlushPoints(t *testing.T) {
+	var m bindings.Map
+	tmpl := MustCompile(Multi(
+		Element("head", Element("title", Text(safe.Const("Hi")))),
+		&SubsectionNode{Name: "rows", Prototype: Multi(Text(bindings.Declare("row", safe.Default)), FlushPoint())},
+	), &m, &Compact)
+	if !tmpl.flushes {
+		t.Fatalf("Compile() => no flushes, wanted the FlushPoint in the subsection found:\n%v", tmpl)
+	}
+	rows := m.Nest("rows")
+	row, _ := rows.Lookup("row")
+	vm := m.MustBind(rows.BindSeries(rows.MustBind(row.BindConst("a")), rows.MustBind(row.BindConst("b"))))
+
+	var b bytes.Buffer
+	var flushed []string
+	opts := &RenderOptions{Flush: func() error {
+		flushed = append(flushed, b.String())
+		return nil
+	}}
+	n, err := tmpl.Page(vm, opts).WriteTo(&b)
+	if err != nil {
+		t.Fatalf("Page.WriteTo: %v", err)
+	}
+	want := "<head><title>Hi</title></head>ab"
+	if diff := cmp.Diff(want, b.String()); diff != "" {
+		t.Errorf("Page.WriteTo() => (-)wanted vs (+)got:\n%s", diff)
+	}
+	if n != int64(len(want)) {
+		t.Errorf("Page.WriteTo() => %d bytes, wanted %d", n, len(want))
+	}
+	wantFlushed := []string{"<head><title>Hi</title></head>a", "<head><title>Hi</title></head>ab"}
+	if diff := cmp.Diff(wantFlushed, flushed); diff != "" {
+		t.Errorf("Page.WriteTo() flushed => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
+
+func TestInlineStaticTemplate(t *testing.T) {
+	var footerBindings bindings.Map
+	footer := MustCompile(Element("footer", Text(safe.Const("Bye"))), &footerBindings, &Compact)
+
+	var m bindings.Map
+	input := Element("body",
+		Element("p", Text(safe.Const("Hi"))),
+		&TemplateNode{Template: footer})
+	tmpl := MustCompile(input, &m, &Compact)
+	if len(tmpl.chunks) != 1 {
+		t.Errorf("Compile(%v) => %d chunks, wanted the static Template inlined into 1:\n%v", input, len(tmpl.chunks), tmpl)
+	}
+	var sb strings.Builder
+	if err := tmpl.GenerateHTML(&sb, m.MustBind()); err != nil {
+		t.Fatalf("GenerateHTML: %v", err)
+	}
+	if diff := cmp.Diff("<body><p>Hi</p><footer>Bye</footer></body>", sb.String()); diff != "" {
+		t.Errorf("GenerateHTML(%v) => (-)wanted vs (+)got:\n%s", input, diff)
+	}
+}
+
+func TestBuffersRowSeams(t *testing.T) {
+	var m bindings.Map
+	tmpl := MustCompile(Element("ul", &SubsectionNode{
+		Name:      "items",
+		Prototype: Element("li", Text(bindings.Declare("item", safe.Default))),
+	}), &m, &Compact)
+	items := m.Nest("items")
+	item, _ := items.Lookup("item")
+	vm := m.MustBind(items.BindSeries(items.MustBind(item.BindConst("a")), items.MustBind(item.BindConst("b"))))
+
+	bufs, err := tmpl.Buffers(vm, &RenderOptions{})
+	if err != nil {
+		t.Fatalf("Buffers: %v", err)
+	}
+	var got []string
+	for _, b := range bufs {
+		got = append(got, string(b))
+	}
+	// The end of the first row and the start of the second are one slice.
+	want := []string{"<ul>", "<li>", "a", "</li><li>", "b", "</li>", "</ul>"}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("Buffers() => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
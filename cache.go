@@ -0,0 +1,95 @@
+package html5
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/the80srobot/html5/bindings"
+)
+
+// TemplateCache compiles Templates lazily, at most once for each combination of
+// a key and CompileOptions. It's safe for concurrent use, and the zero value is
+// ready to use.
+//
+// The cache makes it easy to follow the advice in the bindings package: the
+// Map is declared by the first Compile, and then reused by every request that
+// renders the same Template.
+type TemplateCache struct {
+	mu      sync.Mutex
+	entries map[templateCacheKey]*templateCacheEntry
+	hits    int64
+	misses  int64
+}
+
+// TemplateCacheStats describes the usage of a TemplateCache.
+type TemplateCacheStats struct {
+	// Hits counts calls to Get that didn't need to compile a Template. (Calls
+	// that waited for another goroutine to compile are counted as hits.)
+	Hits int64
+	// Misses counts calls to Get that compiled a Template.
+	Misses int64
+	// Size is the number of cached Templates.
+	Size int
+}
+
+type templateCacheKey struct {
+	key interface{}
+	// CompileOptions isn't comparable (it has slices), so it's formatted with
+	// %#v instead. Pointers, like the Catalog, compare by identity.
+	opts string
+}
+
+type templateCacheEntry struct {
+	once     sync.Once
+	template *Template
+	err      error
+}
+
+// Get returns the Template cached for the key and options. On the first call,
+// Get calls build and compiles the returned Node with a new bindings.Map.
+// Concurrent first calls wait for the same compilation, so build is called at
+// most once for each key and options.
+//
+// The key identifies the Node tree that build returns. It must be comparable -
+// a string naming the page, or a pointer, work well.
+//
+// Compilation errors are cached, just like Templates.
+func (c *TemplateCache) Get(key interface{}, opts *CompileOptions, build func() Node) (*Template, error) {
+	k := templateCacheKey{key: key, opts: fmt.Sprintf("%#v", *opts)}
+
+	c.mu.Lock()
+	e, ok := c.entries[k]
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+		e = &templateCacheEntry{}
+		if c.entries == nil {
+			c.entries = map[templateCacheKey]*templateCacheEntry{k: e}
+		} else {
+			c.entries[k] = e
+		}
+	}
+	c.mu.Unlock()
+
+	e.once.Do(func() {
+		e.template, e.err = Compile(build(), &bindings.Map{}, opts)
+	})
+	return e.template, e.err
+}
+
+// MustGet is like Get, but panics on error.
+func (c *TemplateCache) MustGet(key interface{}, opts *CompileOptions, build func() Node) *Template {
+	t, err := c.Get(key, opts, build)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Stats returns the cache's hit and miss statistics.
+func (c *TemplateCache) Stats() TemplateCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return TemplateCacheStats{Hits: c.hits, Misses: c.misses, Size: len(c.entries)}
+}
@@ -0,0 +1,80 @@
+package html5
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/i18n"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestTemplateCache(t *testing.T) {
+	var cache TemplateCache
+	var builds int32
+	build := func() Node {
+		atomic.AddInt32(&builds, 1)
+		return Element("p", Text(bindings.Declare("greeting", safe.Default)))
+	}
+
+	const goroutines = 8
+	templates := make([]*Template, goroutines)
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			templates[i] = cache.MustGet("greeting", &Compact, build)
+		}(i)
+	}
+	wg.Wait()
+
+	if builds != 1 {
+		t.Errorf("%d concurrent calls to Get called build %d times, wanted once", goroutines, builds)
+	}
+	for i, tmpl := range templates {
+		if tmpl != templates[0] {
+			t.Errorf("Get call #%d returned a different Template: %v vs %v", i, tmpl, templates[0])
+		}
+	}
+
+	// Different options get a separate Template.
+	tidy := cache.MustGet("greeting", &Tidy, build)
+	if tidy == templates[0] {
+		t.Error("Get with Tidy returned the same Template as Get with Compact")
+	}
+
+	var sb strings.Builder
+	if err := GenerateHTML(&sb, tidy, bindings.BindArg{Name: "greeting", Value: safe.Const("Hi!")}); err != nil {
+		t.Fatalf("GenerateHTML: %v", err)
+	}
+	if diff := cmp.Diff("<p>\n  Hi!\n</p>", sb.String()); diff != "" {
+		t.Errorf("GenerateHTML(%v) => (-)wanted vs (+)got:\n%s", tidy, diff)
+	}
+
+	want := TemplateCacheStats{Hits: goroutines - 1, Misses: 2, Size: 2}
+	if diff := cmp.Diff(want, cache.Stats()); diff != "" {
+		t.Errorf("Stats() => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
+
+func TestTemplateCacheLocales(t *testing.T) {
+	var cache TemplateCache
+	build := func() Node { return Text(T("welcome", "Welcome!")) }
+	catalog := &i18n.Catalog{}
+	catalog.SetText("de", "welcome", "Willkommen!")
+
+	de := cache.MustGet("welcome", &CompileOptions{Catalog: catalog, Locales: []string{"de"}}, build)
+	if again := cache.MustGet("welcome", &CompileOptions{Catalog: catalog, Locales: []string{"de"}}, build); again != de {
+		t.Error("Get with equal Locales returned a different Template")
+	}
+	if fr := cache.MustGet("welcome", &CompileOptions{Catalog: catalog, Locales: []string{"fr"}}, build); fr == de {
+		t.Error("Get with different Locales returned the same Template")
+	}
+	if other := cache.MustGet("welcome", &CompileOptions{Catalog: &i18n.Catalog{}, Locales: []string{"de"}}, build); other == de {
+		t.Error("Get with a different Catalog returned the same Template")
+	}
+}
@@ -0,0 +1,57 @@
+package html5
+
+import "fmt"
+
+// Clone returns a deep copy of the Node tree. Changes to the copy, such as
+// applying more Content to its elements, don't affect the original.
+func Clone(n Node) Node {
+	return n.clone()
+}
+
+func cloneNodes(nodes []Node) []Node {
+	if nodes == nil {
+		return nil
+	}
+	c := make([]Node, len(nodes))
+	for i, n := range nodes {
+		c[i] = n.clone()
+	}
+	return c
+}
+
+// FrozenNode is an immutable Node tree, created by Freeze. Nothing can be
+// applied to a FrozenNode, so it can be shared freely: inserted into other
+// trees, and compiled from multiple goroutines at once, with different options.
+type FrozenNode struct {
+	node Node
+}
+
+// Freeze returns an immutable copy of the Node tree. Later changes to the
+// original tree don't affect the copy.
+func Freeze(n Node) *FrozenNode {
+	if f, ok := n.(*FrozenNode); ok {
+		return f
+	}
+	return &FrozenNode{node: n.clone()}
+}
+
+// Apply will insert the frozen tree as a child into the other node.
+func (f *FrozenNode) Apply(n Node) error {
+	switch n := n.(type) {
+	case *ElementNode:
+		n.Contents = append(n.Contents, f)
+	case *MultiNode:
+		n.Contents = append(n.Contents, f)
+	default:
+		return fmt.Errorf("FrozenNode can only be applied to ElementNode or MultiNode, got %v", n)
+	}
+	return nil
+}
+
+func (f *FrozenNode) clone() Node {
+	return f
+}
+
+func (f *FrozenNode) compile(tc *templateCompiler, depth int, opts *CompileOptions) error {
+	return f.node.compile(tc, depth, opts)
+}
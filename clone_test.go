@@ -0,0 +1,101 @@
+package html5
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func testPage() *ElementNode {
+	return Element("html",
+		Element("head", Element("title", Text(bindings.Declare("title", safe.Default)))),
+		Element("body",
+			Element("a",
+				Attribute("href", safe.Const("/")),
+				Attribute("href", safe.Const("/home")),
+				Text(safe.Const("Home"))),
+			&SubsectionNode{
+				Name:      "items",
+				Prototype: Element("p", Attribute("id", bindings.Declare("id", safe.Default)), Text(bindings.Declare("item", safe.Default))),
+			}))
+}
+
+func TestCompileDoesNotModifyTree(t *testing.T) {
+	page := testPage()
+	before := Clone(page)
+	if _, err := Compile(page, &bindings.Map{}, &Tidy); err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+
+	opt := cmp.Comparer(func(x, y Value) bool { return x == y })
+	if diff := cmp.Diff(before, Node(page), opt, cmp.AllowUnexported(FrozenNode{})); diff != "" {
+		t.Errorf("Compile modified the Node tree: (-)before vs (+)after:\n%s", diff)
+	}
+}
+
+func TestClone(t *testing.T) {
+	page := testPage()
+	c := Clone(page).(*ElementNode)
+	Attribute("lang", safe.Const("en")).Apply(c)
+	Element("footer").Apply(c.Contents[1])
+
+	if len(page.Attributes) != 0 {
+		t.Errorf("applying an attribute to a clone changed the original's attributes to %v", page.Attributes)
+	}
+	if got := len(page.Contents[1].(*ElementNode).Contents); got != 2 {
+		t.Errorf("applying an element to a clone's child changed the original child, which has %d children, wanted 2", got)
+	}
+}
+
+func TestFreeze(t *testing.T) {
+	page := testPage()
+	frozen := Freeze(page)
+	if err := Text(safe.Const("Hello")).(*TextNode).Apply(frozen); err == nil {
+		t.Error("applying Content to a FrozenNode should fail")
+	}
+
+	// Changes to the original don't affect the frozen copy.
+	Element("footer").Apply(page)
+	var sb strings.Builder
+	if err := GenerateHTML(&sb, MustCompile(frozen, &bindings.Map{}, &Compact)); err != nil {
+		t.Fatalf("GenerateHTML: %v", err)
+	}
+	if strings.Contains(sb.String(), "footer") {
+		t.Errorf("frozen tree rendered as %q, changes to the original leaked through", sb.String())
+	}
+}
+
+// Compiles and renders the same tree concurrently with different options. Run
+// under the race detector (go test -race) to check that this is safe.
+func TestConcurrentCompile(t *testing.T) {
+	page := Freeze(testPage())
+	values := []bindings.BindArg{
+		{Name: "title", Value: safe.Const("Items")},
+		{
+			Name: "items",
+			NestedRows: [][]bindings.BindArg{
+				{{Name: "id", Value: safe.Const("a")}, {Name: "item", Value: safe.Const("Apple")}},
+				{{Name: "id", Value: safe.Const("b")}, {Name: "item", Value: safe.Const("Banana")}},
+			},
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		for _, opts := range []*CompileOptions{&Compact, &Tidy, &Debug} {
+			wg.Add(1)
+			go func(opts *CompileOptions) {
+				defer wg.Done()
+				got := mustGenerateHTML(t, page, opts, values)
+				if !strings.Contains(got, `<a href="/home">`) || !strings.Contains(got, "Banana") {
+					t.Errorf("GenerateHTML with %v => %q, missing expected content", opts, got)
+				}
+			}(opts)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/the80srobot/html5/i18n"
+)
+
+const html5Path = "github.com/the80srobot/html5"
+
+// message is a single call to html5.T found in source code.
+type message struct {
+	ID       string
+	Text     string
+	Position token.Position
+}
+
+// extractFile returns the calls to html5.T in the file, in source order. Calls
+// whose arguments aren't string literals can't be extracted, and are returned
+// as errors.
+func extractFile(fset *token.FileSet, f *ast.File) ([]message, []error) {
+	// Figure out how the file refers to T. The html5 package itself calls it
+	// unqualified, and so do files with a dot import.
+	var pkgName string
+	bare := f.Name.Name == "html5"
+	for _, imp := range f.Imports {
+		if path, _ := strconv.Unquote(imp.Path.Value); path != html5Path {
+			continue
+		}
+		switch {
+		case imp.Name == nil:
+			pkgName = "html5"
+		case imp.Name.Name == ".":
+			bare = true
+		default:
+			pkgName = imp.Name.Name
+		}
+	}
+	if pkgName == "" && !bare {
+		return nil, nil
+	}
+
+	var (
+		messages []message
+		errs     []error
+	)
+	ast.Inspect(f, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || !isT(call.Fun, pkgName, bare) {
+			return true
+		}
+		pos := fset.Position(call.Pos())
+		if len(call.Args) != 2 {
+			errs = append(errs, fmt.Errorf("%v: T takes 2 arguments, got %d", pos, len(call.Args)))
+			return true
+		}
+		id, ok := stringLiteral(call.Args[0])
+		if !ok {
+			errs = append(errs, fmt.Errorf("%v: message ID must be a string literal", pos))
+			return true
+		}
+		text, ok := stringLiteral(call.Args[1])
+		if !ok {
+			errs = append(errs, fmt.Errorf("%v: default text of %q must be a string literal", pos, id))
+			return true
+		}
+		messages = append(messages, message{ID: id, Text: text, Position: pos})
+		return true
+	})
+	return messages, errs
+}
+
+func isT(fun ast.Expr, pkgName string, bare bool) bool {
+	switch fun := fun.(type) {
+	case *ast.Ident:
+		return bare && fun.Name == "T"
+	case *ast.SelectorExpr:
+		x, ok := fun.X.(*ast.Ident)
+		return ok && pkgName != "" && x.Name == pkgName && fun.Sel.Name == "T"
+	default:
+		return false
+	}
+}
+
+func stringLiteral(e ast.Expr) (string, bool) {
+	lit, ok := e.(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	s, err := strconv.Unquote(lit.Value)
+	return s, err == nil
+}
+
+// goFiles expands the patterns into a sorted list of Go source files. A
+// pattern is a directory, or a directory followed by "/..." to include all its
+// subdirectories, except testdata, vendor and hidden directories. Test files
+// are skipped.
+func goFiles(patterns []string) ([]string, error) {
+	var files []string
+	for _, p := range patterns {
+		dir, recursive := p, false
+		if p == "..." || strings.HasSuffix(p, "/...") {
+			dir, recursive = strings.TrimSuffix(strings.TrimSuffix(p, "..."), "/"), true
+			if dir == "" {
+				dir = "."
+			}
+		}
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if info.IsDir() {
+				if path == dir {
+					return nil
+				}
+				name := info.Name()
+				if !recursive || name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go") {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// extract parses the files and collects their messages into a File for the
+// locale, sorted by ID. Every ID must have the same default text everywhere
+// it's used.
+func extract(locale string, files []string) (*i18n.File, error) {
+	fset := token.NewFileSet()
+	byID := map[string]*i18n.FileMessage{}
+	var errs []error
+	for _, path := range files {
+		f, err := parser.ParseFile(fset, path, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		messages, fileErrs := extractFile(fset, f)
+		errs = append(errs, fileErrs...)
+		for _, m := range messages {
+			fm, ok := byID[m.ID]
+			if !ok {
+				fm = &i18n.FileMessage{ID: m.ID, Other: m.Text}
+				byID[m.ID] = fm
+			} else if fm.Other != m.Text {
+				errs = append(errs, fmt.Errorf("%v: message %q has default text %q, but elsewhere %q", m.Position, m.ID, m.Text, fm.Other))
+			}
+			fm.Positions = append(fm.Positions, fmt.Sprintf("%s:%d:%d", filepath.ToSlash(m.Position.Filename), m.Position.Line, m.Position.Column))
+		}
+	}
+	if len(errs) != 0 {
+		var sb strings.Builder
+		for _, err := range errs {
+			fmt.Fprintf(&sb, "\n\t%v", err)
+		}
+		return nil, fmt.Errorf("%d messages can't be extracted:%s", len(errs), sb.String())
+	}
+
+	file := &i18n.File{Locale: locale, Messages: make([]i18n.FileMessage, 0, len(byID))}
+	for _, fm := range byID {
+		file.Messages = append(file.Messages, *fm)
+	}
+	sort.Slice(file.Messages, func(i, j int) bool { return file.Messages[i].ID < file.Messages[j].ID })
+	return file, nil
+}
+
+// writePO writes the File in the gettext PO format. The message ID is the
+// msgid, and the default text is both the msgstr and a comment, so that
+// translators still see it after replacing the msgstr.
+func writePO(w io.Writer, f *i18n.File) error {
+	if _, err := fmt.Fprintf(w, "msgid \"\"\nmsgstr \"\"\n\"Language: %s\\n\"\n\"Content-Type: text/plain; charset=UTF-8\\n\"\n", poEscape(f.Locale)); err != nil {
+		return err
+	}
+	for _, m := range f.Messages {
+		if _, err := io.WriteString(w, "\n"); err != nil {
+			return err
+		}
+		for _, line := range strings.Split(m.Other, "\n") {
+			if _, err := fmt.Fprintln(w, strings.TrimRight("#. "+line, " ")); err != nil {
+				return err
+			}
+		}
+		for _, p := range m.Positions {
+			if _, err := fmt.Fprintf(w, "#: %s\n", p); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "msgid \"%s\"\nmsgstr \"%s\"\n", poEscape(m.ID), poEscape(m.Other)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var poEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\t", `\t`)
+
+func poEscape(s string) string {
+	return poEscaper.Replace(s)
+}
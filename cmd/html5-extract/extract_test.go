@@ -0,0 +1,190 @@
+package main
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/i18n"
+)
+
+func TestExtractFile(t *testing.T) {
+	for _, tc := range []struct {
+		comment string
+		src     string
+		want    []string
+		wantErr int
+	}{
+		{
+			comment: "qualified",
+			src: `package page
+import "github.com/the80srobot/html5"
+var x = html5.Text(html5.T("welcome", "Welcome!"))`,
+			want: []string{`welcome="Welcome!"@3:20`},
+		},
+		{
+			comment: "alias",
+			src: `package page
+import h "github.com/the80srobot/html5"
+var x = []interface{}{h.T("a", "A"), h.T("b", ` + "`B`" + `)}`,
+			want: []string{`a="A"@3:23`, `b="B"@3:38`},
+		},
+		{
+			comment: "dot import",
+			src: `package page
+import . "github.com/the80srobot/html5"
+var x = Text(T("welcome", "Welcome!"))`,
+			want: []string{`welcome="Welcome!"@3:14`},
+		},
+		{
+			comment: "other package",
+			src: `package page
+import "example.com/html5"
+var x = html5.T("welcome", "Welcome!")`,
+		},
+		{
+			comment: "unqualified without dot import",
+			src: `package page
+import "github.com/the80srobot/html5"
+func T(a, b string) string { return b }
+var x = T("welcome", "Welcome!")
+var y = html5.Text()`,
+		},
+		{
+			comment: "not literals",
+			src: `package page
+import "github.com/the80srobot/html5"
+var id = "welcome"
+var x = html5.T(id, "Welcome!")
+var y = html5.T("welcome", "Welcome" + "!")`,
+			wantErr: 2,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			fset := token.NewFileSet()
+			f, err := parser.ParseFile(fset, "page.go", tc.src, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			messages, errs := extractFile(fset, f)
+			var got []string
+			for _, m := range messages {
+				got = append(got, m.ID+"="+`"`+m.Text+`"@`+strings.TrimPrefix(m.Position.String(), "page.go:"))
+			}
+			if diff := cmp.Diff(tc.want, got); diff != "" {
+				t.Errorf("extractFile(%q)\n => (-)wanted vs (+)got:\n%s", tc.src, diff)
+			}
+			if len(errs) != tc.wantErr {
+				t.Errorf("extractFile(%q) => %d errors (%v), wanted %d", tc.src, len(errs), errs, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtract(t *testing.T) {
+	dir := t.TempDir()
+	for path, src := range map[string]string{
+		"page.go": `package page
+import "github.com/the80srobot/html5"
+var x = html5.T("welcome", "Welcome!")
+var y = html5.T("quote", "Say \"hi\"\n")`,
+		"sub/sub.go": `package sub
+import "github.com/the80srobot/html5"
+var x = html5.T("welcome", "Welcome!")`,
+		"testdata/skipped.go": `package skipped
+import "github.com/the80srobot/html5"
+var x = html5.T("skipped", "Skipped")`,
+		"page_test.go": `package page
+import "github.com/the80srobot/html5"
+var x = html5.T("test", "Test")`,
+	} {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := goFiles([]string{dir + "/..."})
+	if err != nil {
+		t.Fatalf("goFiles: %v", err)
+	}
+	f, err := extract("en", files)
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	for i := range f.Messages {
+		for j, p := range f.Messages[i].Positions {
+			f.Messages[i].Positions[j] = strings.TrimPrefix(p, filepath.ToSlash(dir)+"/")
+		}
+	}
+	want := &i18n.File{
+		Locale: "en",
+		Messages: []i18n.FileMessage{
+			{ID: "quote", Other: "Say \"hi\"\n", Positions: []string{"page.go:4:9"}},
+			{ID: "welcome", Other: "Welcome!", Positions: []string{"page.go:3:9", "sub/sub.go:3:9"}},
+		},
+	}
+	if diff := cmp.Diff(want, f); diff != "" {
+		t.Errorf("extract(%v)\n => (-)wanted vs (+)got:\n%s", files, diff)
+	}
+
+	var sb strings.Builder
+	if err := writePO(&sb, f); err != nil {
+		t.Fatalf("writePO: %v", err)
+	}
+	wantPO := `msgid ""
+msgstr ""
+"Language: en\n"
+"Content-Type: text/plain; charset=UTF-8\n"
+
+#. Say "hi"
+#.
+#: page.go:4:9
+msgid "quote"
+msgstr "Say \"hi\"\n"
+
+#. Welcome!
+#: page.go:3:9
+#: sub/sub.go:3:9
+msgid "welcome"
+msgstr "Welcome!"
+`
+	if diff := cmp.Diff(wantPO, sb.String()); diff != "" {
+		t.Errorf("writePO(%v)\n => (-)wanted vs (+)got:\n%s", f, diff)
+	}
+
+	var c i18n.Catalog
+	sb.Reset()
+	if err := i18n.WriteJSON(&sb, f); err != nil {
+		t.Fatalf("WriteJSON: %v", err)
+	}
+	if err := c.LoadJSON(strings.NewReader(sb.String())); err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+	if s, err := c.Text("en", "welcome"); err != nil || s.String() != "Welcome!" {
+		t.Errorf("Text(en, welcome) after a JSON round trip => (%v, %v), wanted Welcome!", s, err)
+	}
+}
+
+func TestExtractConflict(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "page.go")
+	src := `package page
+import "github.com/the80srobot/html5"
+var x = html5.T("welcome", "Welcome!")
+var y = html5.T("welcome", "Hello!")`
+	if err := ioutil.WriteFile(path, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := extract("en", []string{path}); err == nil {
+		t.Error("extract with conflicting default texts => nil error")
+	}
+}
@@ -0,0 +1,82 @@
+// Command html5-extract finds translatable text in Go source code and writes a
+// message catalog for translators.
+//
+// Translatable text is created with html5.T, whose arguments must be string
+// literals:
+//
+//	Text(html5.T("welcome_banner", "Welcome!"))
+//
+// Usage:
+//
+//	html5-extract [-o file] [-format json|po] [-locale en] [packages]
+//
+// Packages are directories, optionally followed by "/..." to include
+// subdirectories. The default is "./...". The JSON output can be loaded with
+// i18n.Catalog.LoadJSON once translated.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/the80srobot/html5/i18n"
+)
+
+var (
+	output = flag.String("o", "", "write the catalog to this file instead of stdout")
+	format = flag.String("format", "json", "catalog format: json or po")
+	locale = flag.String("locale", "en", "locale of the default texts")
+)
+
+func main() {
+	flag.Parse()
+	if err := run(); err != nil {
+		fmt.Fprintf(os.Stderr, "html5-extract: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run() error {
+	var write func(io.Writer, *i18n.File) error
+	switch *format {
+	case "json":
+		write = i18n.WriteJSON
+	case "po":
+		write = writePO
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	patterns := flag.Args()
+	if len(patterns) == 0 {
+		patterns = []string{"./..."}
+	}
+	files, err := goFiles(patterns)
+	if err != nil {
+		return err
+	}
+	f, err := extract(*locale, files)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if *output != "" {
+		if w, err = os.Create(*output); err != nil {
+			return err
+		}
+	}
+	bw := bufio.NewWriter(w)
+	if err := write(bw, f); err != nil {
+		w.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -2,9 +2,9 @@ package html5
 
 import (
 	"bytes"
-	"fmt"
 
 	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/i18n"
 	"github.com/the80srobot/html5/safe"
 )
 
@@ -13,6 +13,8 @@ type templateCompiler struct {
 	chunks         []chunk
 	separateChunks bool
 	bindings       *bindings.Map
+	catalog        *i18n.Catalog
+	fragments      map[string]*Template
 }
 
 func (tc *templateCompiler) freshLine() bool {
@@ -39,19 +41,28 @@ func (tc *templateCompiler) flush() {
 	if tc.pending == nil {
 		return
 	}
-	tc.chunks = append(tc.chunks, staticChunk{tc.pending.String()})
+	tc.chunks = append(tc.chunks, staticChunk{tc.pending.Bytes()})
 	tc.pending = nil
 }
 
 func (tc *templateCompiler) appendChunk(c chunk) {
+	// Static chunks, such as from inlined Templates, are merged with the
+	// surrounding static data.
+	if sc, ok := c.(staticChunk); ok && !tc.separateChunks {
+		tc.Write(sc.data)
+		return
+	}
 	tc.flush()
 	tc.chunks = append(tc.chunks, c)
 }
 
-func (tc *templateCompiler) appendVar(v bindings.Var, trust safe.TrustLevel) bindings.Var {
-	v = tc.bindings.Attach(v, trust)
+func (tc *templateCompiler) appendVar(v bindings.Var, trust safe.TrustLevel) (bindings.Var, error) {
+	v, err := tc.bindings.TryAttach(v, trust)
+	if err != nil {
+		return v, err
+	}
 	tc.appendChunk(stringBindingChunk{binding: v})
-	return v
+	return v, nil
 }
 
 func (tc *templateCompiler) Write(p []byte) (int, error) {
@@ -95,14 +106,16 @@ const (
 )
 
 func appendTag(tc *templateCompiler, name string, style tagStyle, attributes ...AttributeNode) error {
+	tc.ensureBuffer()
 	if style == tagClose {
-		_, err := fmt.Fprintf(tc, "</%s>", name)
-		return err
+		tc.pending.WriteString("</")
+		tc.pending.WriteString(name)
+		tc.pending.WriteByte('>')
+		return nil
 	}
 
-	if _, err := fmt.Fprintf(tc, "<%s", name); err != nil {
-		return err
-	}
+	tc.pending.WriteByte('<')
+	tc.pending.WriteString(name)
 
 	for _, a := range attributes {
 		if err := appendAttribute(tc, &a); err != nil {
@@ -110,11 +123,12 @@ func appendTag(tc *templateCompiler, name string, style tagStyle, attributes ...
 		}
 	}
 
+	// Attributes may have appended chunks, and so flushed the buffer.
 	if style == tagSelfClose {
-		_, err := fmt.Fprint(tc, "/>")
+		_, err := tc.WriteString("/>")
 		return err
 	}
 
-	_, err := fmt.Fprint(tc, ">")
+	_, err := tc.WriteString(">")
 	return err
 }
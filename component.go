@@ -0,0 +1,155 @@
+package html5
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// Param declares a single parameter of a Component.
+type Param struct {
+	Name string
+	// Trust is the minimum trust level required of the parameter's value.
+	Trust safe.TrustLevel
+	// Required parameters that aren't passed in Args must be bound for every
+	// render: ValueMap.Validate reports them if they're unset, and so does
+	// rendering with RenderOptions.Strict, if the Component uses them.
+	// Optional parameters default to "".
+	Required bool
+}
+
+// Component is a reusable, parameterized piece of the page, such as a card or
+// a navigation bar. Each instance of the Component gets its own Vars, so the
+// same Component can appear on a page many times without collisions.
+//
+// Build is called once per instance, when the instance is compiled. The args
+// map holds a Value for every declared Param - either the Value passed to the
+// instance, or a Var declared for it.
+type Component struct {
+	Name   string
+	Params []Param
+	Build  func(args map[string]Value) Node
+}
+
+// Instance returns a ComponentNode that instantiates the Component with the
+// given Var prefix and arguments.
+func (c *Component) Instance(prefix string, args map[string]Value) *ComponentNode {
+	return &ComponentNode{Component: c, Prefix: prefix, Args: args}
+}
+
+// Required returns the names of the Component's required parameters.
+func (c *Component) Required() []string {
+	var names []string
+	for _, p := range c.Params {
+		if p.Required {
+			names = append(names, p.Name)
+		}
+	}
+	return names
+}
+
+// ComponentNode is a single instance of a Component.
+//
+// Args supplies the instance's parameters, either as safe.String constants, or
+// as Vars. Parameters not present in Args become Vars named Prefix + the
+// parameter name. (So, with Prefix "left_", the parameter "title" becomes the
+// Var "left_title".)
+//
+// If Name is set, then the instance is compiled into a nested Map of that name,
+// and rendered once for each ValueMap in its ValueStream, exactly like a
+// SubsectionNode with the Component as its Prototype.
+type ComponentNode struct {
+	Component *Component
+	Prefix    string
+	Name      string
+	Args      map[string]Value
+}
+
+func (cn *ComponentNode) Apply(n Node) error {
+	switch n := n.(type) {
+	case *ElementNode:
+		n.Contents = append(n.Contents, cn)
+	case *MultiNode:
+		n.Contents = append(n.Contents, cn)
+	default:
+		return fmt.Errorf("ComponentNode can only be applied to ElementNode or MultiNode, got %v", n)
+	}
+	return nil
+}
+
+func (cn *ComponentNode) clone() Node {
+	c := *cn
+	if cn.Args != nil {
+		c.Args = make(map[string]Value, len(cn.Args))
+		for k, v := range cn.Args {
+			c.Args[k] = v
+		}
+	}
+	return &c
+}
+
+func (cn *ComponentNode) compile(tc *templateCompiler, depth int, opts *CompileOptions) error {
+	c := cn.Component
+	if c == nil || c.Build == nil {
+		return errors.New("ComponentNode has no Component to build")
+	}
+
+	// The Map that parameters not in Args are declared in.
+	m := tc.bindings
+	if cn.Name != "" {
+		var err error
+		if m, err = tc.bindings.TryNest(cn.Name); err != nil {
+			return fmt.Errorf("component %q: %w", c.Name, err)
+		}
+	}
+
+	args := make(map[string]Value, len(c.Params))
+	for _, p := range c.Params {
+		arg, ok := cn.Args[p.Name]
+		if !ok || arg == nil {
+			// Declare the Var even if Build doesn't use it, so the parameter
+			// can always be bound, and Validate knows about it.
+			v, err := m.TryDeclare(cn.Prefix+p.Name, p.Trust)
+			if err != nil {
+				return fmt.Errorf("component %q: parameter %q: %w", c.Name, p.Name, err)
+			}
+			if !p.Required && !m.Frozen() {
+				if err := m.SetDefault(v, safe.Const("")); err != nil {
+					return fmt.Errorf("component %q: parameter %q: %w", c.Name, p.Name, err)
+				}
+			}
+			args[p.Name] = bindings.Declare(cn.Prefix+p.Name, p.Trust)
+			continue
+		}
+
+		switch v := arg.(type) {
+		case safe.String:
+			if _, err := safe.Check(v, p.Trust); err != nil {
+				return fmt.Errorf("component %q: parameter %q: %w", c.Name, p.Name, err)
+			}
+			args[p.Name] = v
+		case bindings.Var:
+			args[p.Name] = bindings.Declare(v.Name(), safe.Max(v.TrustLevel(), p.Trust))
+		default:
+			return fmt.Errorf("component %q: parameter %q must be safe.String or bindings.Var, %v (%v) is neither", c.Name, p.Name, v, reflect.TypeOf(v))
+		}
+	}
+
+	for name := range cn.Args {
+		if _, ok := args[name]; !ok {
+			return fmt.Errorf("component %q has no parameter %q", c.Name, name)
+		}
+	}
+
+	n := c.Build(args)
+	if cn.Name != "" {
+		n = &SubsectionNode{Name: cn.Name, Prototype: n}
+	}
+	if err := n.compile(tc, depth, opts); err != nil {
+		return fmt.Errorf("component %q: %w", c.Name, err)
+	}
+	return nil
+}
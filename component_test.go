@@ -0,0 +1,162 @@
+package html5
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+var testCard = &Component{
+	Name: "card",
+	Params: []Param{
+		{Name: "title", Trust: safe.TextSafe, Required: true},
+		{Name: "link", Trust: safe.URLSafe},
+	},
+	Build: func(args map[string]Value) Node {
+		return Element("a", Attribute("href", args["link"]), Text(args["title"]))
+	},
+}
+
+func TestComponent(t *testing.T) {
+	for _, tc := range []struct {
+		comment string
+		input   Node
+		values  []bindings.BindArg
+		output  string
+	}{
+		{
+			comment: "two instances",
+			input: Multi(
+				testCard.Instance("left_", nil),
+				testCard.Instance("right_", nil)),
+			values: []bindings.BindArg{
+				{Name: "left_title", Value: safe.Const("Left")},
+				{Name: "left_link", Value: safe.Const("/left")},
+				{Name: "right_title", Value: safe.Const("Right")},
+				{Name: "right_link", Value: safe.Const("/right")},
+			},
+			output: `<a href="/left">Left</a><a href="/right">Right</a>`,
+		},
+		{
+			comment: "constant and var arguments",
+			input: testCard.Instance("", map[string]Value{
+				"title": safe.Const("Home"),
+				"link":  bindings.Declare("home_url", safe.Default),
+			}),
+			values: []bindings.BindArg{{Name: "home_url", Value: safe.Const("/")}},
+			output: `<a href="/">Home</a>`,
+		},
+		{
+			comment: "nested map",
+			input:   &ComponentNode{Component: testCard, Name: "cards"},
+			values: []bindings.BindArg{
+				{
+					Name: "cards",
+					NestedRows: [][]bindings.BindArg{
+						{{Name: "title", Value: safe.Const("One")}, {Name: "link", Value: safe.Const("/1")}},
+						{{Name: "title", Value: safe.Const("Two")}, {Name: "link", Value: safe.Const("/2")}},
+					},
+				},
+			},
+			output: `<a href="/1">One</a><a href="/2">Two</a>`,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			if diff := cmp.Diff(tc.output, mustGenerateHTML(t, tc.input, &Compact, tc.values)); diff != "" {
+				t.Errorf("GenerateHTML(%v, %v, %v)\n => (-)wanted vs (+)got:\n%s", tc.input, &Compact, tc.values, diff)
+			}
+		})
+	}
+}
+
+func TestComponentStrict(t *testing.T) {
+	for _, tc := range []struct {
+		comment string
+		input   Node
+		values  []bindings.BindArg
+		output  string
+	}{
+		{
+			comment: "prefix",
+			input:   testCard.Instance("left_", map[string]Value{"link": safe.Const("/")}),
+			values:  []bindings.BindArg{{Name: "left_title", Value: safe.Const("Left")}},
+			output:  `<a href="/">Left</a>`,
+		},
+		{
+			comment: "nested map",
+			input:   &ComponentNode{Component: testCard, Name: "cards"},
+			values: []bindings.BindArg{{
+				Name:       "cards",
+				NestedRows: [][]bindings.BindArg{{{Name: "title", Value: safe.Const("One")}, {Name: "link", Value: safe.Const("/1")}}},
+			}},
+			output: `<a href="/1">One</a>`,
+		},
+		{
+			comment: "unused required parameter",
+			input: (&Component{
+				Name:   "ignores",
+				Params: []Param{{Name: "title", Trust: safe.TextSafe, Required: true}},
+				Build:  func(map[string]Value) Node { return Text(safe.Const("static")) },
+			}).Instance("", nil),
+			values: []bindings.BindArg{{Name: "title", Value: safe.Const("Bound anyway")}},
+			output: `static`,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			m := bindings.Map{Strict: true}
+			tmpl, err := Compile(tc.input, &m, &Compact)
+			if err != nil {
+				t.Fatalf("Compile(%v) with a Strict Map: %v", tc.input, err)
+			}
+			vm := m.MustBind()
+			if err := bindings.Bind(vm, tc.values...); err != nil {
+				t.Fatalf("Bind(%v) with a Strict Map: %v", tc.values, err)
+			}
+			var sb strings.Builder
+			if err := tmpl.GenerateHTML(&sb, vm); err != nil {
+				t.Fatalf("GenerateHTML: %v", err)
+			}
+			if diff := cmp.Diff(tc.output, sb.String()); diff != "" {
+				t.Errorf("GenerateHTML(%v, %v, %v)\n => (-)wanted vs (+)got:\n%s", tc.input, &Compact, tc.values, diff)
+			}
+		})
+	}
+
+	// Unbound required parameters are reported, and optional ones default to
+	// "".
+	m := bindings.Map{Strict: true}
+	input := Multi(testCard.Instance("left_", nil), &ComponentNode{Component: testCard, Name: "cards"})
+	tmpl := MustCompile(input, &m, &Compact)
+	vm := m.MustBind()
+	if err := bindings.Bind(vm, bindings.BindArg{Name: "cards", NestedRows: [][]bindings.BindArg{{{Name: "link", Value: safe.Const("/1")}}}}); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	var verr *bindings.ValidationError
+	if err := vm.Validate(); !errors.As(err, &verr) {
+		t.Errorf("Validate() => %v, wanted a ValidationError", err)
+	} else if diff := cmp.Diff([]string{"left_title", "cards[0].title"}, verr.Missing); diff != "" {
+		t.Errorf("Validate() => (-)wanted vs (+)got missing values:\n%s", diff)
+	}
+	if err := tmpl.Render(ioutil.Discard, vm, &RenderOptions{Strict: true}); !errors.Is(err, bindings.ErrUnset) {
+		t.Errorf("Render(%v) with Strict => %v, wanted ErrUnset for the unbound required parameter", input, err)
+	}
+
+	// A frozen Map can't declare the Var, so the parameter can't be bound.
+	m = bindings.Map{Strict: true}
+	m.Freeze()
+	frozenInput := testCard.Instance("", map[string]Value{"link": safe.Const("/")})
+	if _, err := Compile(frozenInput, &m, &Compact); err == nil {
+		t.Errorf("Compile(%v) with a frozen Strict Map should fail on the unbound required parameter", frozenInput)
+	}
+
+	m = bindings.Map{}
+	bogus := testCard.Instance("", map[string]Value{"bogus": safe.Const("Home")})
+	if _, err := Compile(bogus, &m, &Compact); err == nil {
+		t.Errorf("Compile(%v) should fail on an undeclared parameter", bogus)
+	}
+}
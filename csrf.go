@@ -0,0 +1,65 @@
+package html5
+
+import (
+	"html"
+	"io"
+	"strings"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// isPostForm returns whether the form's method is POST. Forms with a bound
+// method can't be told apart, and count as not POST.
+func isPostForm(attributes []AttributeNode) bool {
+	for _, a := range attributes {
+		if a.Name != "method" {
+			continue
+		}
+		s, ok := a.Value.(safe.String)
+		return ok && strings.EqualFold(s.String(), "post")
+	}
+	return false
+}
+
+// csrfInput returns the hidden input with the CSRF token.
+func csrfInput(name string) Node {
+	return Element("input",
+		Attribute("type", safe.Const("hidden")),
+		Attribute("name", safe.EscapeAttribute(name)),
+		Attribute("value", csrfToken{}))
+}
+
+// csrfToken is the Value of RenderOptions.CSRFToken. It comes from the page,
+// not the ValueMap, so forms in subsections and other nested Templates get it,
+// too.
+type csrfToken struct{}
+
+// Check always succeeds, because the token is escaped when it's written.
+func (csrfToken) Check(safe.TrustLevel) bool {
+	return true
+}
+
+func (csrfToken) String() string {
+	return "CSRFToken()"
+}
+
+// csrfChunk writes RenderOptions.CSRFToken.
+type csrfChunk struct{}
+
+func (csrfChunk) build(w io.Writer, _ *bindings.ValueMap, rc renderContext) error {
+	return writeString(w, html.EscapeString(rc.opts.CSRFToken))
+}
+
+func (csrfChunk) size(_ *bindings.ValueMap, rc renderContext) int {
+	return len(html.EscapeString(rc.opts.CSRFToken))
+}
+
+// fingerprint writes nothing: the token changes on every request, but any
+// token derived from the client's secret is as good as any other. The secret
+// is up to the caller. (See httpx.Handler.ETag.)
+func (csrfChunk) fingerprint(*fingerprinter, *bindings.ValueMap, renderContext) {}
+
+func (csrfChunk) String() string {
+	return "csrf{}"
+}
@@ -0,0 +1,62 @@
+package html5
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestCSRFField(t *testing.T) {
+	opts := &CompileOptions{Compact: true, CSRFField: "_csrf"}
+	for _, tc := range []struct {
+		comment string
+		input   Node
+		bind    func(m *bindings.Map, vm *bindings.ValueMap)
+		want    string
+	}{
+		{
+			comment: "post",
+			input:   Element("form", Attribute("method", safe.Const("POST")), Element("button")),
+			want:    `<form method="POST"><input type="hidden" name="_csrf" value="to&#34;ken"><button></button></form>`,
+		},
+		{
+			comment: "post in a subsection",
+			input: Element("ul", &SubsectionNode{Name: "rows", Prototype: Element("li",
+				Element("form", Attribute("method", safe.Const("post")), Element("button")))}),
+			bind: func(m *bindings.Map, vm *bindings.ValueMap) {
+				rows := m.Nest("rows")
+				vm.Set(rows.BindSeries(rows.MustBind()))
+			},
+			want: `<ul><li><form method="post"><input type="hidden" name="_csrf" value="to&#34;ken"><button></button></form></li></ul>`,
+		},
+		{
+			comment: "get",
+			input:   Element("form", Attribute("method", safe.Const("get")), Element("button")),
+			want:    `<form method="get"><button></button></form>`,
+		},
+		{
+			comment: "no method",
+			input:   Element("form", Element("button")),
+			want:    `<form><button></button></form>`,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			var m bindings.Map
+			tmpl := MustCompile(tc.input, &m, opts)
+			vm := m.MustBind()
+			if tc.bind != nil {
+				tc.bind(&m, vm)
+			}
+			var sb strings.Builder
+			if err := tmpl.Render(&sb, vm, &RenderOptions{CSRFToken: `to"ken`, Validate: true}); err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, sb.String()); diff != "" {
+				t.Errorf("Render(%v) => (-)wanted vs (+)got:\n%s", tc.input, diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package html5
+
+import (
+	"fmt"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// DefaultNode gives a Var a default value in the Map the tree is compiled
+// with, so that it doesn't have to be bound for every request. (See
+// bindings.Map.SetDefault.) It renders nothing.
+//
+// Defaults count as set, so IsSet is always true for the Var. If the Map is
+// already frozen, the default is left as it was.
+type DefaultNode struct {
+	Var   bindings.Var
+	Value safe.String
+}
+
+// Default returns a DefaultNode.
+func Default(v bindings.Var, value safe.String) *DefaultNode {
+	return &DefaultNode{Var: v, Value: value}
+}
+
+func (d *DefaultNode) Apply(n Node) error {
+	switch n := n.(type) {
+	case *ElementNode:
+		n.Contents = append(n.Contents, d)
+	case *MultiNode:
+		n.Contents = append(n.Contents, d)
+	default:
+		return fmt.Errorf("DefaultNode can only be applied to ElementNode or MultiNode, got %v", n)
+	}
+	return nil
+}
+
+func (d *DefaultNode) clone() Node {
+	c := *d
+	return &c
+}
+
+func (d *DefaultNode) compile(tc *templateCompiler, _ int, _ *CompileOptions) error {
+	v, err := tc.bindings.TryAttach(d.Var, safe.Default)
+	if err != nil {
+		return err
+	}
+	if tc.bindings.Frozen() {
+		return nil
+	}
+	return tc.bindings.SetDefault(v, d.Value)
+}
+
+func (d *DefaultNode) String() string {
+	return fmt.Sprintf("Default(%v, %v)", d.Var, d.Value)
+}
@@ -0,0 +1,43 @@
+package html5
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestDefault(t *testing.T) {
+	greeting := bindings.Declare("greeting", safe.Default)
+	input := Element("p", Default(greeting, safe.Const("Hello")), Text(greeting))
+	var m bindings.Map
+	tmpl := MustCompile(input, &m, &Compact)
+	attached, _ := m.Lookup("greeting")
+
+	for _, tc := range []struct {
+		comment string
+		vm      *bindings.ValueMap
+		want    string
+	}{
+		{comment: "unbound", vm: m.MustBind(), want: "<p>Hello</p>"},
+		{comment: "bound", vm: m.MustBind(attached.BindConst("Hi")), want: "<p>Hi</p>"},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			var sb strings.Builder
+			if err := tmpl.Render(&sb, tc.vm, &RenderOptions{Validate: true, Strict: true}); err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, sb.String()); diff != "" {
+				t.Errorf("Render(%v) => (-)wanted vs (+)got:\n%s", input, diff)
+			}
+		})
+	}
+
+	// Compiling again with the frozen Map keeps the default.
+	m.Freeze()
+	if _, err := Compile(input, &m, &Compact); err != nil {
+		t.Errorf("Compile(%v) with a frozen Map => %v, wanted no error", input, err)
+	}
+}
@@ -0,0 +1,313 @@
+package html5
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"reflect"
+	"strconv"
+
+	"github.com/the80srobot/html5/bindings"
+)
+
+// DeferredNode represents a slow part of the page, which is rendered out of
+// order. The Placeholder is rendered in its place right away, and the rest of
+// the page continues streaming. The Prototype is rendered once its values are
+// ready, at the end of the <body>, together with a small inline script that
+// swaps it in for the Placeholder.
+//
+// Like a SubsectionNode, the Prototype is compiled with a nested Map, and it's
+// rendered once for every row of the ValueStream bound to it. The ValueStream
+// is usually a bindings.Future, which lets the page render while the values
+// are still loading. Streams that aren't Futures are considered ready
+// immediately.
+//
+// The swap script is inline, so pages with a Content-Security-Policy need to
+// set RenderOptions.Nonce. Deferred content only arrives early if the output is
+// flushed: use a FlushPoint, or CompileOptions.FlushAfterHead.
+type DeferredNode struct {
+	Name      string
+	Prototype Node
+	// Placeholder is shown until the Prototype is ready. It uses the same
+	// bindings as the rest of the page. Optional.
+	Placeholder Node
+}
+
+// Deferred returns a DeferredNode with the given nested Map name, prototype
+// and placeholder, which can be nil.
+func Deferred(name string, prototype, placeholder Node) *DeferredNode {
+	return &DeferredNode{Name: name, Prototype: prototype, Placeholder: placeholder}
+}
+
+func (d *DeferredNode) Apply(n Node) error {
+	switch n := n.(type) {
+	case *ElementNode:
+		n.Contents = append(n.Contents, d)
+	case *MultiNode:
+		n.Contents = append(n.Contents, d)
+	default:
+		return fmt.Errorf("DeferredNode can only be applied to ElementNode or MultiNode, got %v", n)
+	}
+	return nil
+}
+
+func (d *DeferredNode) clone() Node {
+	c := *d
+	if d.Prototype != nil {
+		c.Prototype = d.Prototype.clone()
+	}
+	if d.Placeholder != nil {
+		c.Placeholder = d.Placeholder.clone()
+	}
+	return &c
+}
+
+func (d *DeferredNode) compile(tc *templateCompiler, depth int, opts *CompileOptions) error {
+	m, err := tc.bindings.TryNest(d.Name)
+	if err != nil {
+		return err
+	}
+	// Neither the placeholder nor the content share a line with anything else,
+	// so they're compiled as if they were at the root.
+	nestedOpts := *opts
+	nestedOpts.RootDepth = depth
+	t, err := compileTemplate(d.Prototype, m, &nestedOpts)
+	if err != nil {
+		return err
+	}
+	placeholder := &Template{Bindings: tc.bindings}
+	if d.Placeholder != nil {
+		if placeholder, err = compileTemplate(d.Placeholder, tc.bindings, &nestedOpts); err != nil {
+			return err
+		}
+	}
+	tc.appendChunk(deferredChunk{template: t, placeholder: placeholder, bindings: m})
+	return nil
+}
+
+func (d *DeferredNode) String() string {
+	return fmt.Sprintf("Deferred(%q, %v, %v)", d.Name, d.Prototype, d.Placeholder)
+}
+
+// renderState is shared by all the Templates rendering a single page. It's
+// only allocated for Templates that have deferred content.
+type renderState struct {
+	// The number of deferredChunks rendered so far. Used for unique IDs.
+	next int
+	// Deferred content that hasn't been rendered yet.
+	pending []deferredContent
+}
+
+type deferredContent struct {
+	id       int
+	template *Template
+	stream   bindings.ValueStream
+}
+
+// Markup around deferred content. The placeholder is wrapped in a custom
+// element, which browsers treat like a <span>. The content is in a <template>,
+// so that it's inert until it's moved, and the script that moves it finds it
+// as its previous sibling.
+const (
+	deferredPlaceholderOpen      = `<html5-deferred id="html5-deferred-`
+	deferredPlaceholderOpenEnd   = `">`
+	deferredPlaceholderClose     = `</html5-deferred>`
+	deferredContentOpen          = `<template>`
+	deferredContentClose         = `</template><script`
+	deferredNonceOpen            = ` nonce="`
+	deferredNonceClose           = `"`
+	deferredScriptOpen           = `>(function(s){var t=s.previousElementSibling;document.getElementById("html5-deferred-`
+	deferredScriptClose          = `").replaceWith(t.content);t.remove();s.remove()})(document.currentScript)</script>`
+	deferredPlaceholderMarkupLen = len(deferredPlaceholderOpen) + len(deferredPlaceholderOpenEnd) + len(deferredPlaceholderClose)
+	deferredContentMarkupLen     = len(deferredContentOpen) + len(deferredContentClose) + len(deferredScriptOpen) + len(deferredScriptClose)
+)
+
+type deferredChunk struct {
+	template    *Template
+	placeholder *Template
+	bindings    *bindings.Map
+}
+
+func (dc deferredChunk) build(w io.Writer, vm *bindings.ValueMap, rc renderContext) error {
+	stream, err := rc.getStream(vm, dc.bindings)
+	if err != nil {
+		return err
+	}
+	id := rc.state.next
+	rc.state.next++
+
+	if err := writeString(w, deferredPlaceholderOpen); err != nil {
+		return err
+	}
+	if err := writeString(w, strconv.Itoa(id)); err != nil {
+		return err
+	}
+	if err := writeString(w, deferredPlaceholderOpenEnd); err != nil {
+		return err
+	}
+	if err := dc.placeholder.render(w, vm, rc); err != nil {
+		return err
+	}
+	if err := writeString(w, deferredPlaceholderClose); err != nil {
+		return err
+	}
+
+	// Without values, the placeholder stays.
+	if stream != nil {
+		rc.state.pending = append(rc.state.pending, deferredContent{id: id, template: dc.template, stream: stream})
+	}
+	return nil
+}
+
+// size includes the deferred content and its script, which are written later.
+// Waits for the values to be ready.
+func (dc deferredChunk) size(vm *bindings.ValueMap, rc renderContext) int {
+	id := rc.state.next
+	rc.state.next++
+	idLen := len(strconv.Itoa(id))
+
+	n := deferredPlaceholderMarkupLen + idLen + dc.placeholder.size(vm, rc)
+	if stream := vm.GetStream(dc.bindings); stream != nil {
+		n += deferredContentMarkupLen + idLen + streamSize(stream, dc.template, rc)
+		if rc.opts.Nonce != "" {
+			n += len(deferredNonceOpen) + len(html.EscapeString(rc.opts.Nonce)) + len(deferredNonceClose)
+		}
+	}
+	return n
+}
+
+// fingerprint hashes the placeholder and the content, but not the IDs, which
+// only depend on the order of the chunks. Waits for the values to be ready.
+func (dc deferredChunk) fingerprint(h *fingerprinter, vm *bindings.ValueMap, rc renderContext) {
+	dc.placeholder.fingerprint(h, vm, rc)
+	// Unlike an empty stream, an unset one leaves out the script.
+	stream := vm.GetStream(dc.bindings)
+	if stream == nil {
+		h.writeInt(fingerprintNone)
+		return
+	}
+	streamFingerprint(h, stream, dc.template, rc)
+}
+
+func (dc deferredChunk) String() string {
+	return fmt.Sprintf("deferred{%v}", dc.bindings)
+}
+
+// drainChunk renders all the pending deferred content. Compile puts one at the
+// end of the <body>, if there's deferred content in it.
+type drainChunk struct{}
+
+func (drainChunk) build(w io.Writer, _ *bindings.ValueMap, rc renderContext) error {
+	return rc.state.drain(w, rc)
+}
+
+// size is zero, because deferredChunk.size counts the content.
+func (drainChunk) size(*bindings.ValueMap, renderContext) int {
+	return 0
+}
+
+func (drainChunk) fingerprint(*fingerprinter, *bindings.ValueMap, renderContext) {}
+
+func (drainChunk) String() string {
+	return "drain{}"
+}
+
+// drain renders the pending deferred content, whichever is ready first, until
+// there's none left. Deferred content may itself defer more content, which is
+// rendered in the same way.
+func (s *renderState) drain(w io.Writer, rc renderContext) error {
+	for len(s.pending) != 0 {
+		i := s.ready()
+		dc := s.pending[i]
+		s.pending = append(s.pending[:i], s.pending[i+1:]...)
+		if err := s.renderContent(w, dc, rc); err != nil {
+			return fmt.Errorf("rendering deferred content %d: %w", dc.id, err)
+		}
+		if err := flush(w, rc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// doner is implemented by streams that aren't ready right away, such as
+// bindings.Future.
+type doner interface {
+	Done() <-chan struct{}
+}
+
+// ready returns the index of a pending content whose values are ready,
+// preferring the earliest. If none are ready, it waits for the first one.
+func (s *renderState) ready() int {
+	for i, dc := range s.pending {
+		d, ok := dc.stream.(doner)
+		if !ok {
+			return i
+		}
+		select {
+		case <-d.Done():
+			return i
+		default:
+		}
+	}
+
+	cases := make([]reflect.SelectCase, len(s.pending))
+	for i, dc := range s.pending {
+		cases[i] = reflect.SelectCase{Dir: reflect.SelectRecv, Chan: reflect.ValueOf(dc.stream.(doner).Done())}
+	}
+	i, _, _ := reflect.Select(cases)
+	return i
+}
+
+func (s *renderState) renderContent(w io.Writer, dc deferredContent, rc renderContext) error {
+	if err := writeString(w, deferredContentOpen); err != nil {
+		return err
+	}
+	if err := renderStream(w, dc.stream, dc.template, rc); err != nil {
+		return err
+	}
+	if err := writeString(w, deferredContentClose); err != nil {
+		return err
+	}
+	if rc.opts.Nonce != "" {
+		if err := writeString(w, deferredNonceOpen); err != nil {
+			return err
+		}
+		if err := writeString(w, html.EscapeString(rc.opts.Nonce)); err != nil {
+			return err
+		}
+		if err := writeString(w, deferredNonceClose); err != nil {
+			return err
+		}
+	}
+	if err := writeString(w, deferredScriptOpen); err != nil {
+		return err
+	}
+	if err := writeString(w, strconv.Itoa(dc.id)); err != nil {
+		return err
+	}
+	return writeString(w, deferredScriptClose)
+}
+
+// Deferred returns whether the Template has deferred content. Such Templates
+// should be streamed: anything that needs all of their values up front, like
+// Fingerprint or EstimateSize, waits for all the deferred content.
+func (t *Template) Deferred() bool {
+	return t.deferred
+}
+
+// hasDeferred returns whether any of the chunks, or the Templates nested in
+// them, have deferred content.
+func hasDeferred(chunks []chunk) bool {
+	for _, c := range chunks {
+		if _, ok := c.(deferredChunk); ok {
+			return true
+		}
+		for _, t := range nestedTemplates(c) {
+			if t != nil && t.deferred {
+				return true
+			}
+		}
+	}
+	return false
+}
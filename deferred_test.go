@@ -0,0 +1,157 @@
+package html5
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// wantDeferred returns the markup for deferred content with the given ID.
+func wantDeferred(id int, nonce, content string) string {
+	if nonce != "" {
+		nonce = fmt.Sprintf(" nonce=%q", nonce)
+	}
+	return fmt.Sprintf(`<template>%s</template><script%s>(function(s){var t=s.previousElementSibling;document.getElementById("html5-deferred-%d").replaceWith(t.content);t.remove();s.remove()})(document.currentScript)</script>`, content, nonce, id)
+}
+
+func deferredPage(t *testing.T) (*Template, *bindings.Map) {
+	t.Helper()
+	var m bindings.Map
+	tmpl := MustCompile(Element("body",
+		Element("h1", Text(bindings.Declare("title", safe.Default))),
+		Deferred("slow", Element("p", Text(bindings.Declare("text", safe.Default))), Text(safe.Const("Loading..."))),
+		Deferred("slower", Element("p", Text(bindings.Declare("text", safe.Default))), nil),
+		Element("footer", Text(safe.Const("Bye")))),
+		&m, &Compact)
+	return tmpl, &m
+}
+
+func TestDeferred(t *testing.T) {
+	tmpl, m := deferredPage(t)
+	title, _ := m.Lookup("title")
+	slow := m.Nest("slow")
+	slower := m.Nest("slower")
+	text, _ := slow.Lookup("text")
+	slowerText, _ := slower.Lookup("text")
+
+	slowFuture := bindings.NewFuture()
+	slowerFuture := bindings.NewFuture()
+	vm := m.MustBind(title.BindConst("Hi"), slow.BindStream(slowFuture), slower.BindStream(slowerFuture))
+
+	// The slower widget is ready first, and the slow one is only resolved
+	// after the slower one's content was flushed.
+	slowerFuture.Resolve(slower.MustBind(slowerText.BindConst("World")))
+	var sb strings.Builder
+	var flushes []string
+	opts := &RenderOptions{
+		Nonce: `abc"def`,
+		Flush: func() error {
+			flushes = append(flushes, sb.String())
+			slowFuture.Resolve(slow.MustBind(text.BindConst("Hello")))
+			return nil
+		},
+	}
+	if err := tmpl.Render(&sb, vm, opts); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+
+	head := `<body><h1>Hi</h1>` +
+		`<html5-deferred id="html5-deferred-0">Loading...</html5-deferred>` +
+		`<html5-deferred id="html5-deferred-1"></html5-deferred>` +
+		`<footer>Bye</footer>` +
+		wantDeferred(1, "abc&#34;def", "<p>World</p>")
+	want := head + wantDeferred(0, "abc&#34;def", "<p>Hello</p>") + `</body>`
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("Render() => (-)wanted vs (+)got:\n%s", diff)
+	}
+	if diff := cmp.Diff([]string{head, want[:len(want)-len("</body>")]}, flushes); diff != "" {
+		t.Errorf("Render() flushes => (-)wanted vs (+)got:\n%s", diff)
+	}
+	// EstimateSize uses the default RenderOptions, so there's no nonce.
+	wantSize := len(want) - 2*len(` nonce="abc&#34;def"`)
+	if got := tmpl.EstimateSize(vm); got != wantSize {
+		t.Errorf("EstimateSize() => %d, wanted %d", got, wantSize)
+	}
+
+	// Now both are ready, so they're rendered in order.
+	want = `<body><h1>Hi</h1>` +
+		`<html5-deferred id="html5-deferred-0">Loading...</html5-deferred>` +
+		`<html5-deferred id="html5-deferred-1"></html5-deferred>` +
+		`<footer>Bye</footer>` +
+		wantDeferred(0, "abc&#34;def", "<p>Hello</p>") +
+		wantDeferred(1, "abc&#34;def", "<p>World</p>") +
+		`</body>`
+	bufs, err := tmpl.Buffers(vm, opts)
+	if err != nil {
+		t.Fatalf("Buffers: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := bufs.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if diff := cmp.Diff(want, buf.String()); diff != "" {
+		t.Errorf("Buffers() => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
+
+func TestDeferredUnset(t *testing.T) {
+	tmpl, m := deferredPage(t)
+	title, _ := m.Lookup("title")
+	vm := m.MustBind(title.BindConst("Hi"))
+
+	// The placeholders stay.
+	var sb strings.Builder
+	if err := tmpl.GenerateHTML(&sb, vm); err != nil {
+		t.Fatalf("GenerateHTML: %v", err)
+	}
+	want := `<body><h1>Hi</h1>` +
+		`<html5-deferred id="html5-deferred-0">Loading...</html5-deferred>` +
+		`<html5-deferred id="html5-deferred-1"></html5-deferred>` +
+		`<footer>Bye</footer></body>`
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("GenerateHTML() => (-)wanted vs (+)got:\n%s", diff)
+	}
+
+	if err := tmpl.Render(&sb, vm, &RenderOptions{Strict: true}); !errors.Is(err, bindings.ErrUnset) {
+		t.Errorf("Render() with Strict => %v, wanted ErrUnset", err)
+	}
+}
+
+func TestDeferredNested(t *testing.T) {
+	// Deferred content in a subsection gets a unique ID for every row, and
+	// without a <body>, it's rendered at the end.
+	var m bindings.Map
+	tmpl := MustCompile(&SubsectionNode{
+		Name:      "rows",
+		Prototype: Deferred("widget", Text(bindings.Declare("text", safe.Default)), nil),
+	}, &m, &Compact)
+	rows := m.Nest("rows")
+	widget := rows.Nest("widget")
+	text, _ := widget.Lookup("text")
+
+	var series bindings.ValueSeries
+	for i := 0; i < 2; i++ {
+		f := bindings.NewFuture()
+		f.Resolve(widget.MustBind(text.Bind(safe.EscapeText(fmt.Sprint("Widget ", i)))))
+		series = append(series, rows.MustBind(widget.BindStream(f)))
+	}
+	vm := m.MustBind(rows.BindSeries(series...))
+
+	var sb strings.Builder
+	if err := tmpl.GenerateHTML(&sb, vm); err != nil {
+		t.Fatalf("GenerateHTML: %v", err)
+	}
+	want := `<html5-deferred id="html5-deferred-0"></html5-deferred>` +
+		`<html5-deferred id="html5-deferred-1"></html5-deferred>` +
+		wantDeferred(0, "", "Widget 0") +
+		wantDeferred(1, "", "Widget 1")
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("GenerateHTML() => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
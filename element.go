@@ -16,14 +16,16 @@ type ElementNode struct {
 }
 
 func Element(name string, contents ...Content) *ElementNode {
-	e, ok := elementPrototypes[name]
-	if !ok {
-		e = ElementNode{Name: name}
+	var e *ElementNode
+	if proto, ok := elementPrototypes[name]; ok {
+		e = proto.clone().(*ElementNode)
+	} else {
+		e = &ElementNode{Name: name}
 	}
 	for _, c := range contents {
-		c.Apply(&e)
+		c.Apply(e)
 	}
-	return &e
+	return e
 }
 
 func (e *ElementNode) Apply(n Node) error {
@@ -38,15 +40,26 @@ func (e *ElementNode) Apply(n Node) error {
 	return nil
 }
 
-func (e *ElementNode) deduplicateAttributes() {
+func (e *ElementNode) clone() Node {
+	c := *e
+	if e.Attributes != nil {
+		c.Attributes = append([]AttributeNode(nil), e.Attributes...)
+	}
+	c.Contents = cloneNodes(e.Contents)
+	return &c
+}
+
+// deduplicateAttributes returns the attributes with only the last occurrence
+// of each name kept. The input slice is not modified.
+func deduplicateAttributes(attributes []AttributeNode) []AttributeNode {
 	var attrs []AttributeNode
-	seen := make(map[string]struct{}, len(e.Attributes))
-	for i := len(e.Attributes) - 1; i >= 0; i-- {
-		if _, ok := seen[e.Attributes[i].Name]; ok {
+	seen := make(map[string]struct{}, len(attributes))
+	for i := len(attributes) - 1; i >= 0; i-- {
+		if _, ok := seen[attributes[i].Name]; ok {
 			continue
 		}
-		seen[e.Attributes[i].Name] = struct{}{}
-		attrs = append(attrs, e.Attributes[i])
+		seen[attributes[i].Name] = struct{}{}
+		attrs = append(attrs, attributes[i])
 	}
 
 	// attrs are deduplicated and also reversed.
@@ -54,11 +67,14 @@ func (e *ElementNode) deduplicateAttributes() {
 	for i := 0; i < l/2; i++ {
 		attrs[i], attrs[l-i-1] = attrs[l-i-1], attrs[i]
 	}
-	e.Attributes = attrs
+	return attrs
 }
 
 func (e *ElementNode) compile(tc *templateCompiler, depth int, opts *CompileOptions) error {
-	e.deduplicateAttributes()
+	attributes := deduplicateAttributes(e.Attributes)
+	if opts.AutoDir {
+		attributes = autoDirAttributes(e.Name, attributes)
+	}
 
 	isBlock := e.IndentStyle == Block && !opts.Compact
 
@@ -75,7 +91,7 @@ func (e *ElementNode) compile(tc *templateCompiler, depth int, opts *CompileOpti
 		openingTag = tagSelfClose
 	}
 
-	if err := appendTag(tc, e.Name, openingTag, e.Attributes...); err != nil {
+	if err := appendTag(tc, e.Name, openingTag, attributes...); err != nil {
 		return err
 	}
 
@@ -94,11 +110,22 @@ func (e *ElementNode) compile(tc *templateCompiler, depth int, opts *CompileOpti
 		}
 	}
 
+	if e.Name == "form" && opts.CSRFField != "" && isPostForm(attributes) {
+		if err := csrfInput(opts.CSRFField).compile(tc, depth, opts); err != nil {
+			return err
+		}
+	}
+
+	start := len(tc.chunks)
 	for _, c := range e.Contents {
 		if err := c.compile(tc, depth, opts); err != nil {
 			return err
 		}
 	}
+	// Deferred content goes at the end of the body, after everything else.
+	if e.Name == "body" && hasDeferred(tc.chunks[start:]) {
+		tc.appendChunk(drainChunk{})
+	}
 
 	if isBlock {
 		depth--
@@ -111,6 +138,10 @@ func (e *ElementNode) compile(tc *templateCompiler, depth int, opts *CompileOpti
 		return err
 	}
 
+	if e.Name == "head" && opts.FlushAfterHead {
+		tc.appendChunk(flushChunk{})
+	}
+
 	return nil
 }
 
@@ -0,0 +1,210 @@
+package html5
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/the80srobot/html5/bindings"
+)
+
+// TemplateNode embeds a precompiled Template into another tree. This allows
+// shared parts of the page (headers, footers, widgets) to be compiled once and
+// reused on many pages, each time with the Template's own bindings.Map.
+//
+// The embedded Template's Vars are bound in one of two ways:
+//
+// If Name is set, then the parent Map gets a nested Map with that name, and the
+// TemplateNode is rendered once for each ValueMap in its ValueStream, much like
+// a SubsectionNode. The ValueMaps must be instantiated from Template.Bindings,
+// which is the nested Map's Rows(), for example:
+//
+//	nested := page.Bindings.Nest("header")
+//	vm.Set(nested.BindSeries(header.Bindings.MustBind(...)))
+//
+// so that bindings.Bind can build the rows, too. Rows from the wrong Map are
+// rejected with bindings.ErrWrongMap.
+//
+// Otherwise, Vars maps names of Vars in Template.Bindings to names of Vars in
+// the parent Map, which are declared as needed. At render time, the embedded
+// Template gets a fresh ValueMap with values copied from the parent's.
+// Template's Vars that aren't in Vars are left empty.
+//
+// The embedded Template keeps the formatting it was compiled with, regardless of
+// the parent's CompileOptions.
+type TemplateNode struct {
+	Template *Template
+	Name     string
+	Vars     map[string]string
+}
+
+func (tn *TemplateNode) Apply(n Node) error {
+	switch n := n.(type) {
+	case *ElementNode:
+		n.Contents = append(n.Contents, tn)
+	case *MultiNode:
+		n.Contents = append(n.Contents, tn)
+	default:
+		return fmt.Errorf("TemplateNode can only be applied to ElementNode or MultiNode, got %v", n)
+	}
+	return nil
+}
+
+func (tn *TemplateNode) clone() Node {
+	c := *tn
+	if tn.Vars != nil {
+		c.Vars = make(map[string]string, len(tn.Vars))
+		for k, v := range tn.Vars {
+			c.Vars[k] = v
+		}
+	}
+	return &c
+}
+
+func (tn *TemplateNode) compile(tc *templateCompiler, _ int, _ *CompileOptions) error {
+	if tn.Template == nil {
+		return errors.New("TemplateNode has no Template")
+	}
+
+	if tn.Name != "" {
+		if len(tn.Vars) != 0 {
+			return fmt.Errorf("TemplateNode %q: Name and Vars are mutually exclusive", tn.Name)
+		}
+		m, err := tc.bindings.TryNestRows(tn.Name, tn.Template.Bindings)
+		if err != nil {
+			return err
+		}
+		tc.appendChunk(nestedTemplateChunk{template: tn.Template, bindings: m})
+		return nil
+	}
+
+	// Sort the names, so the compiled chunk doesn't depend on map order.
+	names := make([]string, 0, len(tn.Vars))
+	for inner := range tn.Vars {
+		names = append(names, inner)
+	}
+	sort.Strings(names)
+
+	chunk := mappedTemplateChunk{template: tn.Template}
+	for _, inner := range names {
+		outer := tn.Vars[inner]
+		v, ok := tn.Template.Bindings.Lookup(inner)
+		if !ok {
+			return fmt.Errorf("embedded template has no Var named %q", inner)
+		}
+		ov, err := tc.bindings.TryDeclare(outer, v.TrustLevel())
+		if err != nil {
+			return err
+		}
+		chunk.inner = append(chunk.inner, v)
+		chunk.outer = append(chunk.outer, ov)
+	}
+
+	// Templates without any values can be inlined, fragments and all.
+	if tn.Template.isStatic() {
+		for _, c := range tn.Template.chunks {
+			tc.appendChunk(c)
+		}
+		for id, f := range tn.Template.fragments {
+			if err := addFragment(&tc.fragments, id, f); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	tc.appendChunk(chunk)
+	return nil
+}
+
+// isStatic returns whether the Template only has static chunks, so that its
+// output doesn't depend on values or the locale.
+func (t *Template) isStatic() bool {
+	for _, c := range t.chunks {
+		if _, ok := c.(staticChunk); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+type nestedTemplateChunk struct {
+	template *Template
+	bindings *bindings.Map
+}
+
+func (nc nestedTemplateChunk) build(w io.Writer, vm *bindings.ValueMap, rc renderContext) error {
+	stream, err := rc.getStream(vm, nc.bindings)
+	if stream == nil {
+		return err
+	}
+	return renderStream(w, stream, nc.template, rc)
+}
+
+func (nc nestedTemplateChunk) size(vm *bindings.ValueMap, rc renderContext) int {
+	return streamSize(vm.GetStream(nc.bindings), nc.template, rc)
+}
+
+func (nc nestedTemplateChunk) fingerprint(h *fingerprinter, vm *bindings.ValueMap, rc renderContext) {
+	streamFingerprint(h, vm.GetStream(nc.bindings), nc.template, rc)
+}
+
+func (nc nestedTemplateChunk) String() string {
+	return fmt.Sprintf("nestedTemplate{%q}", nc.bindings.DebugName())
+}
+
+type mappedTemplateChunk struct {
+	template *Template
+	// Parallel slices: the value of outer[i] is copied to inner[i].
+	inner []bindings.Var
+	outer []bindings.Var
+}
+
+func (mc mappedTemplateChunk) build(w io.Writer, vm *bindings.ValueMap, rc renderContext) error {
+	templateValues, err := mc.values(vm)
+	if err != nil {
+		return err
+	}
+	return mc.template.render(w, templateValues, rc)
+}
+
+func (mc mappedTemplateChunk) size(vm *bindings.ValueMap, rc renderContext) int {
+	templateValues, err := mc.values(vm)
+	if err != nil {
+		return 0
+	}
+	return mc.template.size(templateValues, rc)
+}
+
+func (mc mappedTemplateChunk) fingerprint(h *fingerprinter, vm *bindings.ValueMap, rc renderContext) {
+	templateValues, err := mc.values(vm)
+	if err != nil {
+		h.writeInt(fingerprintNone)
+		return
+	}
+	mc.template.fingerprint(h, templateValues, rc)
+}
+
+// values returns a ValueMap for the embedded Template, with the values copied
+// from vm.
+func (mc mappedTemplateChunk) values(vm *bindings.ValueMap) (*bindings.ValueMap, error) {
+	templateValues, err := mc.template.Bindings.Bind()
+	if err != nil {
+		return nil, err
+	}
+	for i, v := range mc.inner {
+		// Unset values stay unset, so the embedded Template can use its own
+		// defaults.
+		if _, ok := vm.Lookup(mc.outer[i]); !ok {
+			continue
+		}
+		if err := templateValues.Set(v.BindVar(vm, mc.outer[i])); err != nil {
+			return nil, err
+		}
+	}
+	return templateValues, nil
+}
+
+func (mc mappedTemplateChunk) String() string {
+	return fmt.Sprintf("mappedTemplate{%v -> %v}", mc.outer, mc.inner)
+}
@@ -0,0 +1,92 @@
+package html5
+
+import (
+	"errors"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestTemplateNode(t *testing.T) {
+	var widgetBindings bindings.Map
+	widget := MustCompile(Element("span",
+		Attribute("class", safe.Const("user")),
+		Text(bindings.Declare("user", safe.Default))), &widgetBindings, &Compact)
+
+	t.Run("mapped vars", func(t *testing.T) {
+		input := Element("p",
+			Text(safe.Const("Posted by ")),
+			&TemplateNode{Template: widget, Vars: map[string]string{"user": "author"}})
+		values := []bindings.BindArg{{Name: "author", Value: safe.Const("Adam")}}
+		want := `<p>Posted by <span class="user">Adam</span></p>`
+		if diff := cmp.Diff(want, mustGenerateHTML(t, input, &Compact, values)); diff != "" {
+			t.Errorf("GenerateHTML(%v, %v, %v)\n => (-)wanted vs (+)got:\n%s", input, &Compact, values, diff)
+		}
+	})
+
+	t.Run("nested map", func(t *testing.T) {
+		input := Element("p",
+			Text(safe.Const("Liked by ")),
+			&TemplateNode{Template: widget, Name: "likes"})
+		var m bindings.Map
+		tmpl, err := Compile(input, &m, &Compact)
+		if err != nil {
+			t.Fatalf("Compile: %v", err)
+		}
+		user, _ := widget.Bindings.Lookup("user")
+		vm := m.MustBind(m.Nest("likes").BindSeries(
+			widget.Bindings.MustBind(user.BindConst("John")),
+			widget.Bindings.MustBind(user.BindConst("Jane"))))
+
+		var sb strings.Builder
+		if err := tmpl.GenerateHTML(&sb, vm); err != nil {
+			t.Fatalf("GenerateHTML: %v", err)
+		}
+		want := `<p>Liked by <span class="user">John</span><span class="user">Jane</span></p>`
+		if diff := cmp.Diff(want, sb.String()); diff != "" {
+			t.Errorf("GenerateHTML(%v, %v)\n => (-)wanted vs (+)got:\n%s", tmpl, vm, diff)
+		}
+	})
+
+	t.Run("nested map with Bind", func(t *testing.T) {
+		input := Element("p", &TemplateNode{Template: widget, Name: "likes"})
+		values := []bindings.BindArg{{
+			Name: "likes",
+			NestedRows: [][]bindings.BindArg{
+				{{Name: "user", Value: safe.Const("John")}},
+				{{Name: "user", Value: safe.Const("Jane")}},
+			},
+		}}
+		want := `<p><span class="user">John</span><span class="user">Jane</span></p>`
+		if diff := cmp.Diff(want, mustGenerateHTML(t, input, &Compact, values)); diff != "" {
+			t.Errorf("GenerateHTML(%v, %v, %v)\n => (-)wanted vs (+)got:\n%s", input, &Compact, values, diff)
+		}
+	})
+
+	t.Run("nested map with rows from the wrong map", func(t *testing.T) {
+		input := &TemplateNode{Template: widget, Name: "likes"}
+		var m bindings.Map
+		tmpl := MustCompile(input, &m, &Compact)
+		likes := m.Nest("likes")
+		if err := m.MustBind().Set(likes.BindSeries(likes.MustBind())); !errors.Is(err, bindings.ErrWrongMap) {
+			t.Errorf("Set(BindSeries) with a row from %s => %v, wanted %v", likes.DebugName(), err, bindings.ErrWrongMap)
+		}
+
+		vm := m.MustBind(likes.BindStream(iteratorStream{likes.MustBind()}))
+		if err := tmpl.GenerateHTML(ioutil.Discard, vm); !errors.Is(err, bindings.ErrWrongMap) {
+			t.Errorf("GenerateHTML with a streamed row from %s => %v, wanted %v", likes.DebugName(), err, bindings.ErrWrongMap)
+		}
+	})
+
+	t.Run("unknown var", func(t *testing.T) {
+		input := &TemplateNode{Template: widget, Vars: map[string]string{"nope": "author"}}
+		var m bindings.Map
+		if _, err := Compile(input, &m, &Compact); err == nil {
+			t.Errorf("Compile(%v) should fail on a Var missing from the embedded template", input)
+		}
+	})
+}
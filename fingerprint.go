@@ -0,0 +1,88 @@
+package html5
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+
+	"github.com/the80srobot/html5/bindings"
+)
+
+// Fingerprint returns a hash of everything that determines the output of
+// GenerateHTML for the ValueMap: the static HTML of the Template, and the
+// values it uses, including those in nested streams. Pages with the same
+// fingerprint render the same, so it makes a good HTTP ETag.
+//
+// Fingerprint walks the same values as GenerateHTML, but only hashes the
+// static HTML once, at compile time. Like EstimateSize, it waits for deferred
+// content to be ready. The fingerprint is stable across processes, but not
+// across versions of this package.
+func (t *Template) Fingerprint(vm *bindings.ValueMap) string {
+	h := fingerprinter{hash: sha256.New()}
+	t.fingerprint(&h, vm, t.newRenderContext(vm, &defaultRenderOptions))
+	return hex.EncodeToString(h.hash.Sum(nil))
+}
+
+func (t *Template) fingerprint(h *fingerprinter, vm *bindings.ValueMap, rc renderContext) {
+	h.hash.Write(t.digest[:])
+	for _, c := range t.chunks {
+		c.fingerprint(h, vm, rc)
+	}
+}
+
+// fingerprinter hashes the values a Template renders. Everything is length
+// prefixed or delimited, so that different values can't run together into the
+// same hash input.
+type fingerprinter struct {
+	hash    hash.Hash
+	scratch [binary.MaxVarintLen64]byte
+}
+
+func (h *fingerprinter) writeInt(n int) {
+	l := binary.PutVarint(h.scratch[:], int64(n))
+	h.hash.Write(h.scratch[:l])
+}
+
+func (h *fingerprinter) writeString(s string) {
+	h.writeInt(len(s))
+	h.hash.Write([]byte(s))
+}
+
+// Markers around the rows of a stream.
+const (
+	fingerprintRow = iota
+	fingerprintEnd
+	// Written instead of a Template that has no output.
+	fingerprintNone
+)
+
+// streamFingerprint hashes the values of each row of the stream.
+func streamFingerprint(h *fingerprinter, stream bindings.ValueStream, t *Template, rc renderContext) {
+	if stream != nil {
+		next := stream.Stream()
+		for values := next(); values != nil; values = next() {
+			h.writeInt(fingerprintRow)
+			t.fingerprint(h, values, rc)
+		}
+	}
+	h.writeInt(fingerprintEnd)
+}
+
+// staticDigest hashes the static chunks and their positions. Templates nested
+// in the other chunks are hashed when the fingerprint is computed, because
+// which of them render depends on the values.
+func staticDigest(chunks []chunk) [sha256.Size]byte {
+	h := fingerprinter{hash: sha256.New()}
+	h.writeInt(len(chunks))
+	for i, c := range chunks {
+		if sc, ok := c.(staticChunk); ok {
+			h.writeInt(i)
+			h.writeInt(len(sc.data))
+			h.hash.Write(sc.data)
+		}
+	}
+	var digest [sha256.Size]byte
+	h.hash.Sum(digest[:0])
+	return digest
+}
@@ -0,0 +1,77 @@
+package html5
+
+import (
+	"testing"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestFingerprint(t *testing.T) {
+	page := func(heading string) Node {
+		return Element("body",
+			Element(heading, Text(bindings.Declare("title", safe.Default))),
+			&SwitchNode{
+				Cases:   []Case{{Condition: IsSet(bindings.Declare("subtitle", safe.Default)), Output: Element("h2", Text(bindings.Declare("subtitle", safe.Default)))}},
+				Default: Element("h2", Text(safe.Const("No subtitle"))),
+			},
+			&SubsectionNode{Name: "items", Prototype: Element("p", Text(bindings.Declare("item", safe.Default)))})
+	}
+	var m bindings.Map
+	tmpl := MustCompile(page("h1"), &m, &Compact)
+	var otherM bindings.Map
+	other := MustCompile(page("h3"), &otherM, &Compact)
+
+	bind := func(m *bindings.Map, title, subtitle string, items ...string) *bindings.ValueMap {
+		titleVar, _ := m.Lookup("title")
+		nested := m.Nest("items")
+		item, _ := nested.Lookup("item")
+		var rows bindings.ValueSeries
+		for _, s := range items {
+			rows = append(rows, nested.MustBind(item.Bind(safe.EscapeText(s))))
+		}
+		values := []bindings.Value{titleVar.Bind(safe.EscapeText(title)), nested.BindSeries(rows...)}
+		if subtitle != "" {
+			subtitleVar, _ := m.Lookup("subtitle")
+			values = append(values, subtitleVar.Bind(safe.EscapeText(subtitle)))
+		}
+		return m.MustBind(values...)
+	}
+
+	want := tmpl.Fingerprint(bind(&m, "Hello", "", "a", "b"))
+	if got := tmpl.Fingerprint(bind(&m, "Hello", "", "a", "b")); got != want {
+		t.Errorf("Fingerprint() with the same values => %s, wanted %s", got, want)
+	}
+
+	for _, tc := range []struct {
+		comment string
+		tmpl    *Template
+		vm      *bindings.ValueMap
+	}{
+		{comment: "different title", tmpl: tmpl, vm: bind(&m, "Hi", "", "a", "b")},
+		{comment: "different switch case", tmpl: tmpl, vm: bind(&m, "Hello", "World", "a", "b")},
+		{comment: "different row", tmpl: tmpl, vm: bind(&m, "Hello", "", "a", "c")},
+		{comment: "values moved between rows", tmpl: tmpl, vm: bind(&m, "Hello", "", "ab", "")},
+		{comment: "more rows", tmpl: tmpl, vm: bind(&m, "Hello", "", "a", "b", "")},
+		{comment: "value moved out of the rows", tmpl: tmpl, vm: bind(&m, "Helloa", "", "b")},
+		{comment: "different static HTML", tmpl: other, vm: bind(&otherM, "Hello", "", "a", "b")},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			if got := tc.tmpl.Fingerprint(tc.vm); got == want {
+				t.Errorf("Fingerprint() => %s, wanted a different fingerprint", got)
+			}
+		})
+	}
+}
+
+func TestFingerprintLocale(t *testing.T) {
+	var m bindings.Map
+	tmpl := MustCompile(Element("p", Text(T("hello", "Hello"))), &m, &CompileOptions{Compact: true, Catalog: testCatalog()})
+	en := m.MustBind()
+	en.Locale = "en"
+	he := m.MustBind()
+	he.Locale = "he"
+	if tmpl.Fingerprint(en) == tmpl.Fingerprint(he) {
+		t.Errorf("Fingerprint() => the same fingerprint for en and he, wanted different ones")
+	}
+}
@@ -0,0 +1,98 @@
+package html5
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/the80srobot/html5/bindings"
+)
+
+// FlushPointNode flushes the output when it's reached during rendering, so
+// that the client can start working on everything before it (e.g. fetch the
+// stylesheets in <head>) while the rest of the page is still rendering.
+//
+// The output is flushed by calling RenderOptions.Flush, if set, or else the
+// Flush method of the writer, if it has one. (Both http.Flusher and writers
+// like bufio.Writer are supported.) Otherwise, FlushPointNode does nothing.
+type FlushPointNode struct{}
+
+// FlushPoint returns a FlushPointNode. (See also CompileOptions.FlushAfterHead.)
+func FlushPoint() *FlushPointNode {
+	return &FlushPointNode{}
+}
+
+func (f *FlushPointNode) Apply(n Node) error {
+	switch n := n.(type) {
+	case *ElementNode:
+		n.Contents = append(n.Contents, f)
+	case *MultiNode:
+		n.Contents = append(n.Contents, f)
+	default:
+		return fmt.Errorf("FlushPointNode can only be applied to ElementNode or MultiNode, got %v", n)
+	}
+	return nil
+}
+
+func (f *FlushPointNode) clone() Node {
+	return &FlushPointNode{}
+}
+
+func (f *FlushPointNode) compile(tc *templateCompiler, _ int, _ *CompileOptions) error {
+	tc.appendChunk(flushChunk{})
+	return nil
+}
+
+func (f *FlushPointNode) String() string {
+	return "FlushPoint()"
+}
+
+// hasFlush returns whether any of the chunks, or the chunks of any nested
+// Template, is a flushChunk.
+func hasFlush(chunks []chunk) bool {
+	for _, c := range chunks {
+		if _, ok := c.(flushChunk); ok {
+			return true
+		}
+		for _, t := range nestedTemplates(c) {
+			if t != nil && t.flushes {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type flushChunk struct{}
+
+func (flushChunk) build(w io.Writer, _ *bindings.ValueMap, rc renderContext) error {
+	return flush(w, rc)
+}
+
+// flush sends the output written so far to the client, with
+// RenderOptions.Flush, or the writer's Flush method.
+func flush(w io.Writer, rc renderContext) error {
+	// Gathered output is written all at once, at the end.
+	if _, ok := w.(*gatherWriter); ok {
+		return nil
+	}
+	if rc.opts.Flush != nil {
+		return rc.opts.Flush()
+	}
+	switch w := w.(type) {
+	case interface{ Flush() error }:
+		return w.Flush()
+	case interface{ Flush() }:
+		w.Flush()
+	}
+	return nil
+}
+
+func (flushChunk) size(*bindings.ValueMap, renderContext) int {
+	return 0
+}
+
+func (flushChunk) fingerprint(*fingerprinter, *bindings.ValueMap, renderContext) {}
+
+func (flushChunk) String() string {
+	return "flush{}"
+}
@@ -0,0 +1,88 @@
+package html5
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// flushRecorder records the output written before each flush.
+type flushRecorder struct {
+	strings.Builder
+	flushes []string
+}
+
+func (fr *flushRecorder) Flush() {
+	fr.flushes = append(fr.flushes, fr.String())
+}
+
+func TestFlushPoint(t *testing.T) {
+	input := Element("html",
+		Element("head", Element("title", Text(safe.Const("Hi")))),
+		Element("body",
+			Element("h1", Text(safe.Const("Hello"))),
+			FlushPoint(),
+			Element("p", Text(safe.Const("World")))))
+
+	for _, tc := range []struct {
+		comment string
+		opts    *CompileOptions
+		want    []string
+	}{
+		{
+			comment: "flush point",
+			opts:    &Compact,
+			want:    []string{"<html><head><title>Hi</title></head><body><h1>Hello</h1>"},
+		},
+		{
+			comment: "flush after head",
+			opts:    &CompileOptions{Compact: true, FlushAfterHead: true},
+			want: []string{
+				"<html><head><title>Hi</title></head>",
+				"<html><head><title>Hi</title></head><body><h1>Hello</h1>",
+			},
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			var m bindings.Map
+			tmpl := MustCompile(input, &m, tc.opts)
+			var fr flushRecorder
+			if err := tmpl.GenerateHTML(&fr, m.MustBind()); err != nil {
+				t.Fatalf("GenerateHTML: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, fr.flushes); diff != "" {
+				t.Errorf("GenerateHTML(%v) flushes => (-)wanted vs (+)got:\n%s", input, diff)
+			}
+
+			// The callback takes precedence over the writer.
+			fr = flushRecorder{}
+			calls := 0
+			opts := &RenderOptions{Flush: func() error {
+				calls++
+				return nil
+			}}
+			if err := tmpl.Render(&fr, m.MustBind(), opts); err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if calls != len(tc.want) || len(fr.flushes) != 0 {
+				t.Errorf("Render(%v) with a Flush callback => %d calls and %d writer flushes, wanted %d calls and none", input, calls, len(fr.flushes), len(tc.want))
+			}
+		})
+	}
+
+	// Writers with Flush() error work too.
+	var m bindings.Map
+	tmpl := MustCompile(input, &m, &Compact)
+	var sb strings.Builder
+	bw := bufio.NewWriterSize(&sb, 4096)
+	if err := tmpl.GenerateHTML(bw, m.MustBind()); err != nil {
+		t.Fatalf("GenerateHTML: %v", err)
+	}
+	if diff := cmp.Diff("<html><head><title>Hi</title></head><body><h1>Hello</h1>", sb.String()); diff != "" {
+		t.Errorf("GenerateHTML(bufio.Writer) before the final flush => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
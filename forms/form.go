@@ -0,0 +1,326 @@
+// Package forms renders HTML forms from typed field definitions, and parses
+// and validates their submissions.
+//
+// Each Field is tied to two Vars: one named after the field's id, for its
+// value, and one with the suffix "_error", for its validation error. A Form
+// compiles into an ordinary html5 Node, so it can be part of any page. After
+// a submission fails validation, binding the Submission to the page's
+// ValueMap re-renders the form with the submitted values and the errors next
+// to the fields they belong to.
+package forms
+
+import (
+	"fmt"
+
+	"github.com/the80srobot/html5"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// Kind is the type of a Field, which decides how it's rendered and validated.
+type Kind int
+
+const (
+	Text Kind = iota
+	Email
+	Number
+	Select
+	Checkbox
+	Radio
+	TextArea
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Text:
+		return "Text"
+	case Email:
+		return "Email"
+	case Number:
+		return "Number"
+	case Select:
+		return "Select"
+	case Checkbox:
+		return "Checkbox"
+	case Radio:
+		return "Radio"
+	case TextArea:
+		return "TextArea"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Option is one of the choices of a Select or Radio field.
+type Option struct {
+	Value string
+	Label string
+}
+
+// Field is a single input of a Form.
+type Field struct {
+	// Name is the name the field is submitted under. (See Form.ValueVar and
+	// Form.ErrorVar for the names of its Vars.)
+	Name  string
+	Label string
+	Kind  Kind
+	// Required fields must not be empty. Required checkboxes must be checked.
+	Required bool
+	// Options are the choices of Select and Radio fields.
+	Options []Option
+	// Validate, if set, is called with the submitted value, after the checks
+	// for the Kind pass. The error's message is shown to the user.
+	Validate func(value string) error
+}
+
+// Form is a set of Fields, rendered as a <form> element.
+type Form struct {
+	// ID is the id of the form element, and the prefix of the ids of its
+	// fields. It must be unique in the page.
+	ID     string
+	Action string
+	// Method is "get" or "post". Defaults to "post".
+	Method string
+	Fields []Field
+	// Submit is the label of the submit button. If empty, there's no button.
+	Submit string
+}
+
+// ValueVar returns the Var that holds the field's value. It's named after the
+// field's id, so that forms in the same page don't share values. It defaults
+// to "".
+func (f *Form) ValueVar(field *Field) bindings.Var {
+	return bindings.Declare(f.id(field), safe.Default)
+}
+
+// ErrorVar returns the Var that holds the field's validation error. It
+// defaults to "", which means there's no error.
+func (f *Form) ErrorVar(field *Field) bindings.Var {
+	return bindings.Declare(f.id(field)+"_error", safe.Default)
+}
+
+// Field returns the field with the given name, or nil.
+func (f *Form) Field(name string) *Field {
+	for i := range f.Fields {
+		if f.Fields[i].Name == name {
+			return &f.Fields[i]
+		}
+	}
+	return nil
+}
+
+// Node returns the <form> element, with a label, an input and an error message
+// for each field.
+func (f *Form) Node() html5.Node {
+	method := safe.Const("post")
+	if f.Method == "get" {
+		method = safe.Const("get")
+	}
+	form := html5.Element("form", attr("id", f.ID), html5.Attribute("method", method))
+	if f.Action != "" {
+		html5.Attribute("action", safe.EscapeURL(f.Action)).Apply(form)
+	}
+	// Blank forms render without binding anything.
+	for i := range f.Fields {
+		form.Contents = append(form.Contents,
+			html5.Default(f.ValueVar(&f.Fields[i]), safe.Const("")),
+			html5.Default(f.ErrorVar(&f.Fields[i]), safe.Const("")),
+			f.fieldNode(&f.Fields[i]))
+	}
+	if f.Submit != "" {
+		form.Contents = append(form.Contents, html5.Element("button",
+			html5.Attribute("type", safe.Const("submit")),
+			html5.Text(safe.EscapeText(f.Submit))))
+	}
+	return form
+}
+
+// id returns the id of the field's input.
+func (f *Form) id(field *Field) string {
+	if f.ID == "" {
+		return field.Name
+	}
+	return f.ID + "-" + field.Name
+}
+
+func (f *Form) fieldNode(field *Field) html5.Node {
+	id := f.id(field)
+	errorID := id + "-error"
+	label := html5.Element("label", attr("for", id), html5.Text(safe.EscapeText(field.Label)))
+	value, errorVar := f.ValueVar(field), f.ErrorVar(field)
+	errorMessage := &html5.SwitchNode{
+		Cases: []html5.Case{{
+			Condition: notEmpty(errorVar),
+			Output:    html5.Element("span", attr("id", errorID), html5.Attribute("class", safe.Const("error")), html5.Text(errorVar)),
+		}},
+		Vars: []bindings.Var{errorVar},
+	}
+
+	var input html5.Node
+	switch field.Kind {
+	case Checkbox:
+		// The checkbox goes before its label.
+		return html5.Element("div", html5.Attribute("class", safe.Const("field")),
+			invalidSwitch(errorVar, func(aria []html5.Content) html5.Node {
+				return &html5.SwitchNode{
+					Cases: []html5.Case{{
+						Condition: html5.IsTrue(value),
+						Output:    checkbox(field, id, append(aria, html5.Attribute("checked", safe.Const("")))),
+					}},
+					Default: checkbox(field, id, aria),
+					Vars:    []bindings.Var{value},
+				}
+			}, errorID),
+			label, errorMessage)
+	case Radio:
+		return html5.Element("div", html5.Attribute("class", safe.Const("field")),
+			invalidSwitch(errorVar, func(aria []html5.Content) html5.Node {
+				return radioGroup(field, value, id, aria)
+			}, errorID),
+			errorMessage)
+	case Select:
+		input = invalidSwitch(errorVar, func(aria []html5.Content) html5.Node {
+			return selectElement(field, value, id, aria)
+		}, errorID)
+	case TextArea:
+		input = invalidSwitch(errorVar, func(aria []html5.Content) html5.Node {
+			return html5.Element("textarea", append(common(field, id, aria),
+				html5.Indent(html5.Inline),
+				html5.Text(value))...)
+		}, errorID)
+	default:
+		input = invalidSwitch(errorVar, func(aria []html5.Content) html5.Node {
+			return html5.Element("input", append(common(field, id, aria),
+				html5.Attribute("type", inputType(field.Kind)),
+				html5.Attribute("value", value))...)
+		}, errorID)
+	}
+	return html5.Element("div", html5.Attribute("class", safe.Const("field")), label, input, errorMessage)
+}
+
+// invalidSwitch renders the input with ARIA attributes that point to the error
+// message, if there is one.
+func invalidSwitch(errorVar bindings.Var, input func(aria []html5.Content) html5.Node, errorID string) html5.Node {
+	return &html5.SwitchNode{
+		Cases: []html5.Case{{
+			Condition: notEmpty(errorVar),
+			Output: input([]html5.Content{
+				html5.Attribute("aria-invalid", safe.Const("true")),
+				attr("aria-describedby", errorID),
+			}),
+		}},
+		Default: input(nil),
+	}
+}
+
+// common returns the attributes every input has.
+func common(field *Field, id string, aria []html5.Content) []html5.Content {
+	contents := []html5.Content{attr("id", id), attr("name", field.Name)}
+	if field.Required {
+		contents = append(contents, html5.Attribute("required", safe.Const("")))
+	}
+	return append(contents, aria...)
+}
+
+func inputType(k Kind) safe.String {
+	switch k {
+	case Email:
+		return safe.Const("email")
+	case Number:
+		return safe.Const("number")
+	default:
+		return safe.Const("text")
+	}
+}
+
+func checkbox(field *Field, id string, attrs []html5.Content) html5.Node {
+	return html5.Element("input", append(common(field, id, attrs),
+		html5.Attribute("type", safe.Const("checkbox")),
+		html5.Attribute("value", safe.Const("on")))...)
+}
+
+func selectElement(field *Field, value bindings.Var, id string, aria []html5.Content) html5.Node {
+	sel := html5.Element("select", common(field, id, aria)...)
+	for _, o := range field.Options {
+		sel.Contents = append(sel.Contents, &html5.SwitchNode{
+			Cases: []html5.Case{{
+				Condition: equals(value, o.Value),
+				Output:    option(o, html5.Attribute("selected", safe.Const(""))),
+			}},
+			Default: option(o),
+			Vars:    []bindings.Var{value},
+		})
+	}
+	return sel
+}
+
+func option(o Option, attrs ...html5.Content) html5.Node {
+	return html5.Element("option", append([]html5.Content{
+		attr("value", o.Value),
+		html5.Text(safe.EscapeText(o.Label)),
+	}, attrs...)...)
+}
+
+// radioGroup renders a fieldset with a radio button and a label for each
+// option. The group, not the buttons, carries the ARIA attributes.
+func radioGroup(field *Field, value bindings.Var, id string, aria []html5.Content) html5.Node {
+	group := html5.Element("fieldset", append([]html5.Content{
+		attr("id", id),
+		html5.Attribute("role", safe.Const("radiogroup")),
+	}, aria...)...)
+	group.Contents = append(group.Contents, html5.Element("legend", html5.Text(safe.EscapeText(field.Label))))
+	for i, o := range field.Options {
+		optionID := fmt.Sprintf("%s-%d", id, i)
+		radio := func(checked bool) html5.Node {
+			input := html5.Element("input",
+				attr("id", optionID),
+				attr("name", field.Name),
+				html5.Attribute("type", safe.Const("radio")),
+				attr("value", o.Value))
+			if field.Required {
+				html5.Attribute("required", safe.Const("")).Apply(input)
+			}
+			if checked {
+				html5.Attribute("checked", safe.Const("")).Apply(input)
+			}
+			return input
+		}
+		group.Contents = append(group.Contents,
+			&html5.SwitchNode{
+				Cases:   []html5.Case{{Condition: equals(value, o.Value), Output: radio(true)}},
+				Default: radio(false),
+				Vars:    []bindings.Var{value},
+			},
+			html5.Element("label", attr("for", optionID), html5.Text(safe.EscapeText(o.Label))))
+	}
+	return group
+}
+
+// equals returns a Condition that's true if the Var is set to the value.
+func equals(v bindings.Var, value string) html5.Condition {
+	return func(vm *bindings.ValueMap) bool {
+		attached, ok := vm.Vars.Lookup(v.Name())
+		if !ok {
+			return false
+		}
+		s, ok := vm.Lookup(attached)
+		return ok && s == value
+	}
+}
+
+// notEmpty returns a Condition that's true if the Var is set to anything but
+// "".
+func notEmpty(v bindings.Var) html5.Condition {
+	return func(vm *bindings.ValueMap) bool {
+		attached, ok := vm.Vars.Lookup(v.Name())
+		if !ok {
+			return false
+		}
+		s, ok := vm.Lookup(attached)
+		return ok && s != ""
+	}
+}
+
+func attr(name, value string) *html5.AttributeNode {
+	return html5.Attribute(name, safe.EscapeAttribute(value))
+}
@@ -0,0 +1,146 @@
+package forms
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5"
+	"github.com/the80srobot/html5/bindings"
+)
+
+func testForm() *Form {
+	return &Form{
+		ID:     "signup",
+		Action: "/signup?step=1&next=2",
+		Fields: []Field{
+			{Name: "name", Label: "Name", Required: true},
+			{Name: "email", Label: "Email", Kind: Email, Required: true},
+			{Name: "age", Label: "Age", Kind: Number, Validate: func(v string) error {
+				if v == "0" {
+					return errors.New("Too young.")
+				}
+				return nil
+			}},
+			{Name: "plan", Label: "Plan", Kind: Select, Options: []Option{{Value: "free", Label: "Free"}, {Value: "pro", Label: "Pro & more"}}},
+			{Name: "size", Label: "Size", Kind: Radio, Options: []Option{{Value: "s", Label: "Small"}, {Value: "l", Label: "Large"}}},
+			{Name: "bio", Label: "Bio", Kind: TextArea},
+			{Name: "terms", Label: "I agree", Kind: Checkbox, Required: true},
+		},
+		Submit: "Sign up",
+	}
+}
+
+func render(t *testing.T, f *Form, bind func(vm *bindings.ValueMap) error) string {
+	t.Helper()
+	var m bindings.Map
+	tmpl, err := html5.Compile(f.Node(), &m, &html5.Compact)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	vm := m.MustBind()
+	if bind != nil {
+		if err := bind(vm); err != nil {
+			t.Fatalf("Bind: %v", err)
+		}
+	}
+	// Every Var has a default, so even blank forms render strictly.
+	var sb strings.Builder
+	if err := tmpl.Render(&sb, vm, &html5.RenderOptions{Strict: true, Validate: true}); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	return sb.String()
+}
+
+func TestFormNode(t *testing.T) {
+	// A blank form, with nothing bound.
+	want := `<form id="signup" method="post" action="/signup?step=1&next=2">` +
+		`<div class="field"><label for="signup-name">Name</label><input id="signup-name" name="name" required="" type="text" value=""></div>` +
+		`<div class="field"><label for="signup-email">Email</label><input id="signup-email" name="email" required="" type="email" value=""></div>` +
+		`<div class="field"><label for="signup-age">Age</label><input id="signup-age" name="age" type="number" value=""></div>` +
+		`<div class="field"><label for="signup-plan">Plan</label><select id="signup-plan" name="plan"><option value="free">Free</option><option value="pro">Pro &amp; more</option></select></div>` +
+		`<div class="field"><fieldset id="signup-size" role="radiogroup"><legend>Size</legend>` +
+		`<input id="signup-size-0" name="size" type="radio" value="s"><label for="signup-size-0">Small</label>` +
+		`<input id="signup-size-1" name="size" type="radio" value="l"><label for="signup-size-1">Large</label></fieldset></div>` +
+		`<div class="field"><label for="signup-bio">Bio</label><textarea id="signup-bio" name="bio"></textarea></div>` +
+		`<div class="field"><input id="signup-terms" name="terms" required="" type="checkbox" value="on"><label for="signup-terms">I agree</label></div>` +
+		`<button type="submit">Sign up</button></form>`
+	if diff := cmp.Diff(want, render(t, testForm(), nil)); diff != "" {
+		t.Errorf("Form.Node() => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
+
+func TestFormRerender(t *testing.T) {
+	f := testForm()
+	s := f.Parse(url.Values{
+		"name":  {"<Adam>"},
+		"email": {"nope"},
+		"plan":  {"pro"},
+		"size":  {"l"},
+		"bio":   {"Hi & bye"},
+	})
+	if s.Valid() {
+		t.Fatalf("Parse() => valid, wanted errors")
+	}
+
+	want := `<form id="signup" method="post" action="/signup?step=1&next=2">` +
+		`<div class="field"><label for="signup-name">Name</label><input id="signup-name" name="name" required="" type="text" value="&lt;Adam&gt;"></div>` +
+		`<div class="field"><label for="signup-email">Email</label><input id="signup-email" name="email" required="" aria-invalid="true" aria-describedby="signup-email-error" type="email" value="nope">` +
+		`<span id="signup-email-error" class="error">Enter a valid email address.</span></div>` +
+		`<div class="field"><label for="signup-age">Age</label><input id="signup-age" name="age" type="number" value=""></div>` +
+		`<div class="field"><label for="signup-plan">Plan</label><select id="signup-plan" name="plan"><option value="free">Free</option><option value="pro" selected="">Pro &amp; more</option></select></div>` +
+		`<div class="field"><fieldset id="signup-size" role="radiogroup"><legend>Size</legend>` +
+		`<input id="signup-size-0" name="size" type="radio" value="s"><label for="signup-size-0">Small</label>` +
+		`<input id="signup-size-1" name="size" type="radio" value="l" checked=""><label for="signup-size-1">Large</label></fieldset></div>` +
+		`<div class="field"><label for="signup-bio">Bio</label><textarea id="signup-bio" name="bio">Hi &amp; bye</textarea></div>` +
+		`<div class="field"><input id="signup-terms" name="terms" required="" aria-invalid="true" aria-describedby="signup-terms-error" type="checkbox" value="on"><label for="signup-terms">I agree</label>` +
+		`<span id="signup-terms-error" class="error">This field is required.</span></div>` +
+		`<button type="submit">Sign up</button></form>`
+	if diff := cmp.Diff(want, render(t, f, s.Bind)); diff != "" {
+		t.Errorf("Form.Node() after Submission.Bind => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
+
+func TestFormCheckboxChecked(t *testing.T) {
+	f := &Form{ID: "f", Fields: []Field{{Name: "news", Label: "News", Kind: Checkbox}}}
+	s := f.Parse(url.Values{"news": {"yes"}})
+	want := `<form id="f" method="post"><div class="field"><input id="f-news" name="news" checked="" type="checkbox" value="on"><label for="f-news">News</label></div></form>`
+	if diff := cmp.Diff(want, render(t, f, s.Bind)); diff != "" {
+		t.Errorf("Form.Node() with a checked checkbox => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
+
+func TestFormsInOnePage(t *testing.T) {
+	// Forms with different IDs have their own Vars, even for fields with the
+	// same name, and their errors default to empty, so the page validates
+	// without binding them.
+	login := &Form{ID: "login", Fields: []Field{{Name: "email", Label: "Email", Kind: Email}}}
+	signup := &Form{ID: "signup", Fields: []Field{{Name: "email", Label: "Email", Kind: Email}}}
+	var m bindings.Map
+	tmpl, err := html5.Compile(html5.Multi(login.Node(), signup.Node()), &m, &html5.Compact)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	vm := m.MustBind()
+	if err := login.Parse(url.Values{"email": {"nope"}}).Bind(vm); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+	if err := signup.Bind(vm, map[string]string{"email": `a"b@example.com`}, nil); err != nil {
+		t.Fatalf("Bind: %v", err)
+	}
+
+	want := `<form id="login" method="post"><div class="field"><label for="login-email">Email</label>` +
+		`<input id="login-email" name="email" aria-invalid="true" aria-describedby="login-email-error" type="email" value="nope">` +
+		`<span id="login-email-error" class="error">Enter a valid email address.</span></div></form>` +
+		`<form id="signup" method="post"><div class="field"><label for="signup-email">Email</label>` +
+		`<input id="signup-email" name="email" type="email" value="a&#34;b@example.com"></div></form>`
+	var sb strings.Builder
+	if err := tmpl.Render(&sb, vm, &html5.RenderOptions{Validate: true}); err != nil {
+		t.Fatalf("Render: %v", err)
+	}
+	if diff := cmp.Diff(want, sb.String()); diff != "" {
+		t.Errorf("Render() => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
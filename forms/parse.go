@@ -0,0 +1,151 @@
+package forms
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// Messages shown for the built-in checks.
+const (
+	MessageRequired = "This field is required."
+	MessageEmail    = "Enter a valid email address."
+	MessageNumber   = "Enter a number."
+	MessageOption   = "Choose one of the options."
+)
+
+// Submission is a parsed and validated submission of a Form.
+type Submission struct {
+	form *Form
+	// Values holds the submitted value of each field, by name. Checked
+	// checkboxes are "on", and unchecked ones are empty.
+	Values map[string]string
+	// Errors holds the validation error of each invalid field, by name.
+	Errors map[string]string
+}
+
+// Parse reads the values of the form's fields, and validates them. Values that
+// aren't the form's fields are ignored.
+func (f *Form) Parse(values url.Values) *Submission {
+	s := &Submission{form: f, Values: make(map[string]string, len(f.Fields))}
+	for i := range f.Fields {
+		field := &f.Fields[i]
+		v := strings.TrimSpace(values.Get(field.Name))
+		if field.Kind == Checkbox && v != "" {
+			v = "on"
+		}
+		s.Values[field.Name] = v
+		if msg := field.check(v); msg != "" {
+			if s.Errors == nil {
+				s.Errors = make(map[string]string)
+			}
+			s.Errors[field.Name] = msg
+		}
+	}
+	return s
+}
+
+// check returns the error message for the value, or "" if it's valid.
+func (field *Field) check(v string) string {
+	if v == "" {
+		if field.Required {
+			return MessageRequired
+		}
+		return ""
+	}
+
+	switch field.Kind {
+	case Email:
+		if addr, err := mail.ParseAddress(v); err != nil || addr.Address != v {
+			return MessageEmail
+		}
+	case Number:
+		if _, err := strconv.ParseFloat(v, 64); err != nil {
+			return MessageNumber
+		}
+	case Select, Radio:
+		if !field.hasOption(v) {
+			return MessageOption
+		}
+	}
+	if field.Validate != nil {
+		if err := field.Validate(v); err != nil {
+			return err.Error()
+		}
+	}
+	return ""
+}
+
+func (field *Field) hasOption(v string) bool {
+	for _, o := range field.Options {
+		if o.Value == v {
+			return true
+		}
+	}
+	return false
+}
+
+// Valid returns whether all the fields passed validation.
+func (s *Submission) Valid() bool {
+	return len(s.Errors) == 0
+}
+
+// Bind sets the submitted values and errors on the ValueMap, so that the form
+// re-renders as it was submitted, with the errors shown.
+func (s *Submission) Bind(vm *bindings.ValueMap) error {
+	return s.form.Bind(vm, s.Values, s.Errors)
+}
+
+// Bind sets the values and errors of the form's fields, by name, on the
+// ValueMap. Use it to prefill the form. Fields missing from the maps stay
+// unset. The form must have been compiled with the ValueMap's Map.
+func (f *Form) Bind(vm *bindings.ValueMap, values, errors map[string]string) error {
+	for i := range f.Fields {
+		field := &f.Fields[i]
+		if v, ok := values[field.Name]; ok {
+			value, err := f.bindValue(vm, field, v)
+			if err != nil {
+				return err
+			}
+			if err := vm.Set(value); err != nil {
+				return err
+			}
+		}
+		if msg, ok := errors[field.Name]; ok {
+			ev, ok := vm.Vars.Lookup(f.ErrorVar(field).Name())
+			if !ok {
+				return fmt.Errorf("field %q: no Var %s in the Map", field.Name, f.ErrorVar(field).Name())
+			}
+			if err := vm.Set(ev.Bind(safe.EscapeText(msg))); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// bindValue returns the value of the field, escaped for where the field's Kind
+// renders it.
+func (f *Form) bindValue(vm *bindings.ValueMap, field *Field, s string) (bindings.Value, error) {
+	name := f.ValueVar(field).Name()
+	v, ok := vm.Vars.Lookup(name)
+	if !ok {
+		return bindings.Value{}, fmt.Errorf("field %q: no Var %s in the Map", field.Name, name)
+	}
+	switch field.Kind {
+	case Checkbox:
+		return v.BindBool(s != ""), nil
+	case Select, Radio:
+		// Only compared with the options, never rendered.
+		return v.Bind(safe.UntrustedString(s)), nil
+	case TextArea:
+		return v.Bind(safe.EscapeText(s)), nil
+	default:
+		return v.Bind(safe.EscapeAttribute(s)), nil
+	}
+}
@@ -0,0 +1,69 @@
+package forms
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	f := testForm()
+	for _, tc := range []struct {
+		comment    string
+		values     url.Values
+		wantValues map[string]string
+		wantErrors map[string]string
+	}{
+		{
+			comment: "valid",
+			values: url.Values{
+				"name":  {"  Adam "},
+				"email": {"adam@example.com"},
+				"age":   {"42"},
+				"plan":  {"free"},
+				"terms": {"on"},
+				"extra": {"ignored"},
+			},
+			wantValues: map[string]string{"name": "Adam", "email": "adam@example.com", "age": "42", "plan": "free", "size": "", "bio": "", "terms": "on"},
+		},
+		{
+			comment:    "missing required fields",
+			values:     url.Values{},
+			wantValues: map[string]string{"name": "", "email": "", "age": "", "plan": "", "size": "", "bio": "", "terms": ""},
+			wantErrors: map[string]string{"name": MessageRequired, "email": MessageRequired, "terms": MessageRequired},
+		},
+		{
+			comment: "invalid values",
+			values: url.Values{
+				"name":  {"Adam"},
+				"email": {"Adam <adam@example.com>"},
+				"age":   {"old"},
+				"plan":  {"gold"},
+				"size":  {"xl"},
+				"terms": {"1"},
+			},
+			wantValues: map[string]string{"name": "Adam", "email": "Adam <adam@example.com>", "age": "old", "plan": "gold", "size": "xl", "bio": "", "terms": "on"},
+			wantErrors: map[string]string{"email": MessageEmail, "age": MessageNumber, "plan": MessageOption, "size": MessageOption},
+		},
+		{
+			comment:    "custom validation",
+			values:     url.Values{"name": {"Adam"}, "email": {"adam@example.com"}, "age": {"0"}, "terms": {"on"}},
+			wantValues: map[string]string{"name": "Adam", "email": "adam@example.com", "age": "0", "plan": "", "size": "", "bio": "", "terms": "on"},
+			wantErrors: map[string]string{"age": "Too young."},
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			s := f.Parse(tc.values)
+			if diff := cmp.Diff(tc.wantValues, s.Values); diff != "" {
+				t.Errorf("Parse(%v) => (-)wanted vs (+)got values:\n%s", tc.values, diff)
+			}
+			if diff := cmp.Diff(tc.wantErrors, s.Errors); diff != "" {
+				t.Errorf("Parse(%v) => (-)wanted vs (+)got errors:\n%s", tc.values, diff)
+			}
+			if got, want := s.Valid(), len(tc.wantErrors) == 0; got != want {
+				t.Errorf("Parse(%v).Valid() => %v, wanted %v", tc.values, got, want)
+			}
+		})
+	}
+}
@@ -1,61 +1,112 @@
 package html5
 
 import (
-	"bufio"
-	"bytes"
 	"io"
+	"sync"
+	"unicode"
 	"unicode/utf8"
 )
 
+// Size of the pooled buffers writeString copies strings through.
+const writeBufferSize = 4096
+
+var writeBufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, writeBufferSize)
+		return &b
+	},
+}
+
+// writeString is like io.WriteString, but writers that don't implement
+// io.StringWriter get the string copied through a pooled buffer, instead of a
+// new byte slice.
+func writeString(w io.Writer, s string) error {
+	if sw, ok := w.(io.StringWriter); ok {
+		_, err := sw.WriteString(s)
+		return err
+	}
+
+	bp := writeBufferPool.Get().(*[]byte)
+	defer writeBufferPool.Put(bp)
+	for len(s) > 0 {
+		n := copy(*bp, s)
+		if _, err := w.Write((*bp)[:n]); err != nil {
+			return err
+		}
+		s = s[n:]
+	}
+	return nil
+}
+
 func fprintRawNewline(w io.Writer, depth int, indent string) error {
-	if _, err := w.Write([]byte{'\n'}); err != nil {
+	if err := writeString(w, "\n"); err != nil {
 		return err
 	}
 	for i := 0; i < depth; i++ {
-		if _, err := io.WriteString(w, indent); err != nil {
+		if err := writeString(w, indent); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func fprintBlockText(w io.Writer, depth, width int, indent string, text io.Reader) error {
+// fprintBlockText writes the text, breaking lines between words so that they
+// don't exceed the width, if possible. Whitespace between words on the same
+// line is replaced with a single space. If the width is zero or less, the text
+// is written as is.
+func fprintBlockText(w io.Writer, depth, width int, indent string, text string) error {
 	if width <= 0 {
-		_, err := io.Copy(w, text)
-		return err
+		return writeString(w, text)
 	}
 
-	var line bytes.Buffer
 	runeCount := depth * len(indent)
+	first := true
+	for {
+		word, rest := nextWord(text)
+		if word == "" {
+			return nil
+		}
+		text = rest
 
-	scanner := bufio.NewScanner(text)
-	scanner.Split(bufio.ScanWords)
-	for scanner.Scan() {
-		word := scanner.Bytes()
-		l := utf8.RuneCount(word)
-		// Flush on overrun, but don't create empty lines (could happen if the
-		// word is longer than the line length and there are no bytes buffered.)
-		if runeCount+l > width && line.Len() > 0 {
-			if _, err := line.WriteTo(w); err != nil {
-				return err
-			}
-			line.Reset()
-			runeCount = depth*len(indent) + l
-			if err := fprintRawNewline(w, depth, indent); err != nil {
-				return err
+		l := utf8.RuneCountInString(word)
+		if !first {
+			// Break on overrun, but don't create empty lines (could happen if
+			// the word is longer than the line length.)
+			if runeCount+1+l > width {
+				if err := fprintRawNewline(w, depth, indent); err != nil {
+					return err
+				}
+				runeCount = depth * len(indent)
+			} else {
+				if err := writeString(w, " "); err != nil {
+					return err
+				}
+				runeCount++
 			}
 		}
-		line.Write(word)
+		if err := writeString(w, word); err != nil {
+			return err
+		}
+		runeCount += l
+		first = false
 	}
+}
 
-	if err := scanner.Err(); err != nil {
-		return err
+// nextWord returns the first run of non-space characters in the text, and the
+// text after it.
+func nextWord(text string) (string, string) {
+	start := -1
+	for i, r := range text {
+		if unicode.IsSpace(r) {
+			if start >= 0 {
+				return text[start:i], text[i:]
+			}
+		} else if start < 0 {
+			start = i
+		}
 	}
-
-	if line.Len() > 0 {
-		_, err := line.WriteTo(w)
-		return err
+	if start < 0 {
+		return "", ""
 	}
-
-	return nil
+	return text[start:], ""
 }
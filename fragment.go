@@ -0,0 +1,184 @@
+package html5
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/the80srobot/html5/bindings"
+)
+
+// ErrNoFragment is returned when rendering a fragment the Template doesn't
+// have.
+var ErrNoFragment = errors.New("no such fragment")
+
+// FragmentNode marks a region of the page that can be rendered on its own,
+// with the same ValueMap as the whole page. This is useful for partial page
+// updates, such as with htmx or Turbo.
+//
+// In the page, the FragmentNode renders just like its Content. The compiler
+// also compiles the Content into a standalone Template, which shares the
+// page's Map, and can be rendered with Template.GenerateFragment.
+//
+// Fragment IDs must be unique in the page. Fragments can't be inside
+// subsections, deferred content or embedded Templates that aren't static,
+// because there would be no single set of values to render them with.
+type FragmentNode struct {
+	ID      string
+	Content Node
+}
+
+// Fragment returns a FragmentNode with the given ID and content.
+func Fragment(id string, content Node) *FragmentNode {
+	return &FragmentNode{ID: id, Content: content}
+}
+
+func (f *FragmentNode) Apply(n Node) error {
+	switch n := n.(type) {
+	case *ElementNode:
+		n.Contents = append(n.Contents, f)
+	case *MultiNode:
+		n.Contents = append(n.Contents, f)
+	default:
+		return fmt.Errorf("FragmentNode can only be applied to ElementNode or MultiNode, got %v", n)
+	}
+	return nil
+}
+
+func (f *FragmentNode) clone() Node {
+	c := *f
+	if f.Content != nil {
+		c.Content = f.Content.clone()
+	}
+	return &c
+}
+
+func (f *FragmentNode) compile(tc *templateCompiler, depth int, opts *CompileOptions) error {
+	// Inside another fragment's standalone Template, this is just content:
+	// its own standalone Template comes from the inline pass. Otherwise, every
+	// level of nesting would double the work.
+	if opts.inFragment {
+		return f.Content.compile(tc, depth, opts)
+	}
+	// The standalone Template starts at the root, because it's not inside
+	// anything when it's rendered on its own.
+	fragmentOpts := *opts
+	fragmentOpts.RootDepth = 0
+	fragmentOpts.inFragment = true
+	t, err := compileTemplate(f.Content, tc.bindings, &fragmentOpts)
+	if err != nil {
+		return fmt.Errorf("fragment %q: %w", f.ID, err)
+	}
+	if err := addFragment(&tc.fragments, f.ID, t); err != nil {
+		return err
+	}
+	return f.Content.compile(tc, depth, opts)
+}
+
+func (f *FragmentNode) String() string {
+	return fmt.Sprintf("Fragment(%q, %v)", f.ID, f.Content)
+}
+
+func addFragment(fragments *map[string]*Template, id string, t *Template) error {
+	if _, ok := (*fragments)[id]; ok {
+		return fmt.Errorf("duplicate fragment %q", id)
+	}
+	if *fragments == nil {
+		*fragments = make(map[string]*Template)
+	}
+	(*fragments)[id] = t
+	return nil
+}
+
+// collectFragments adds the fragments of the Templates nested in the chunks.
+// Switch cases, placeholders and the decorations of subsections are rendered
+// with the page's values, so their fragments are the page's too. Subsections,
+// deferred content and embedded Templates are rendered with other values, so
+// they can't have fragments.
+func collectFragments(fragments *map[string]*Template, chunks []chunk) error {
+	for _, c := range chunks {
+		switch c := c.(type) {
+		case switchChunk:
+			for _, t := range c.templates {
+				if t == nil {
+					continue
+				}
+				for id, f := range t.fragments {
+					if err := addFragment(fragments, id, f); err != nil {
+						return err
+					}
+				}
+			}
+		case deferredChunk:
+			for id, f := range c.placeholder.fragments {
+				if err := addFragment(fragments, id, f); err != nil {
+					return err
+				}
+			}
+			if id, ok := anyFragment(c.template); ok {
+				return fmt.Errorf("fragment %q is in deferred content %s", id, c.bindings.DebugName())
+			}
+		case nestedTemplateChunk:
+			if id, ok := anyFragment(c.template); ok {
+				return fmt.Errorf("fragment %q is in embedded Template %s", id, c.bindings.DebugName())
+			}
+		case mappedTemplateChunk:
+			if id, ok := anyFragment(c.template); ok {
+				return fmt.Errorf("fragment %q is in embedded Template %s", id, c.template.Bindings.DebugName())
+			}
+		case subsectionChunk:
+			if id, ok := anyFragment(&c.template); ok {
+				return fmt.Errorf("fragment %q is in subsection %s", id, c.bindings.DebugName())
+			}
+			for _, t := range c.decorations() {
+				if t == nil {
+					continue
+				}
+				for id, f := range t.fragments {
+					if err := addFragment(fragments, id, f); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// anyFragment returns the ID of one of the Template's fragments, if it has
+// any.
+func anyFragment(t *Template) (string, bool) {
+	for id := range t.fragments {
+		return id, true
+	}
+	return "", false
+}
+
+// Fragments returns the IDs of the Template's fragments, in order.
+func (t *Template) Fragments() []string {
+	ids := make([]string, 0, len(t.fragments))
+	for id := range t.fragments {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// GenerateFragment writes the HTML for the fragment with the given ID, with
+// values from the ValueMap, which must be instantiated from t.Bindings, just
+// like for GenerateHTML. It returns ErrNoFragment if there's no such fragment.
+func (t *Template) GenerateFragment(w io.Writer, id string, vm *bindings.ValueMap) error {
+	return t.RenderFragment(w, id, vm, &defaultRenderOptions)
+}
+
+// RenderFragment is like GenerateFragment, but allows the caller to specify
+// RenderOptions. Validation checks the whole ValueMap, including values the
+// fragment doesn't use.
+func (t *Template) RenderFragment(w io.Writer, id string, vm *bindings.ValueMap, opts *RenderOptions) error {
+	f, ok := t.fragments[id]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNoFragment, id)
+	}
+	return f.Render(w, vm, opts)
+}
@@ -0,0 +1,179 @@
+package html5
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestFragment(t *testing.T) {
+	var m bindings.Map
+	tmpl := MustCompile(Element("body",
+		Element("h1", Text(bindings.Declare("title", safe.Default))),
+		Fragment("comments", Element("ul",
+			Attribute("id", safe.Const("comments")),
+			Fragment("count", Element("li", Text(bindings.Declare("count", safe.Default)))),
+			&SubsectionNode{Name: "comments", Prototype: Element("li", Text(bindings.Declare("comment", safe.Default)))})),
+		&SwitchNode{
+			Cases:   []Case{{Condition: IsSet(bindings.Declare("notice", safe.Default)), Output: Fragment("notice", Element("p", Text(bindings.Declare("notice", safe.Default))))}},
+			Default: nil,
+		}),
+		&m, &Compact)
+
+	title, _ := m.Lookup("title")
+	count, _ := m.Lookup("count")
+	notice, _ := m.Lookup("notice")
+	comments := m.Nest("comments")
+	comment, _ := comments.Lookup("comment")
+	vm := m.MustBind(
+		title.BindConst("Post"),
+		count.BindConst("2"),
+		notice.BindConst("New!"),
+		comments.BindSeries(
+			comments.MustBind(comment.BindConst("First")),
+			comments.MustBind(comment.BindConst("Second"))))
+
+	if diff := cmp.Diff([]string{"comments", "count", "notice"}, tmpl.Fragments()); diff != "" {
+		t.Errorf("Fragments() => (-)wanted vs (+)got:\n%s", diff)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.GenerateHTML(&sb, vm); err != nil {
+		t.Fatalf("GenerateHTML: %v", err)
+	}
+	if diff := cmp.Diff(`<body><h1>Post</h1><ul id="comments"><li>2</li><li>First</li><li>Second</li></ul><p>New!</p></body>`, sb.String()); diff != "" {
+		t.Errorf("GenerateHTML() => (-)wanted vs (+)got:\n%s", diff)
+	}
+
+	for _, tc := range []struct {
+		id   string
+		want string
+	}{
+		{id: "comments", want: `<ul id="comments"><li>2</li><li>First</li><li>Second</li></ul>`},
+		{id: "count", want: `<li>2</li>`},
+		{id: "notice", want: `<p>New!</p>`},
+	} {
+		var sb strings.Builder
+		if err := tmpl.GenerateFragment(&sb, tc.id, vm); err != nil {
+			t.Errorf("GenerateFragment(%q): %v", tc.id, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, sb.String()); diff != "" {
+			t.Errorf("GenerateFragment(%q) => (-)wanted vs (+)got:\n%s", tc.id, diff)
+		}
+	}
+
+	if err := tmpl.GenerateFragment(&sb, "nope", vm); !errors.Is(err, ErrNoFragment) {
+		t.Errorf("GenerateFragment(%q) => %v, wanted ErrNoFragment", "nope", err)
+	}
+}
+
+func TestFragmentTidy(t *testing.T) {
+	// The fragment is indented as if it was at the root.
+	var m bindings.Map
+	tmpl := MustCompile(Element("body", Element("main", Fragment("list", Element("ul", Element("li", Text(safe.Const("Item"))))))), &m, &Tidy)
+	var sb strings.Builder
+	if err := tmpl.GenerateFragment(&sb, "list", m.MustBind()); err != nil {
+		t.Fatalf("GenerateFragment: %v", err)
+	}
+	if diff := cmp.Diff("<ul>\n  <li>Item</li>\n</ul>", sb.String()); diff != "" {
+		t.Errorf("GenerateFragment() => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
+
+func TestFragmentErrors(t *testing.T) {
+	for _, tc := range []struct {
+		comment string
+		input   Node
+	}{
+		{
+			comment: "duplicate",
+			input:   Multi(Fragment("a", Element("p")), Fragment("a", Element("div"))),
+		},
+		{
+			comment: "duplicate in a switch",
+			input: Multi(Fragment("a", Element("p")), &SwitchNode{
+				Cases: []Case{{Condition: IsSet(bindings.Declare("x", safe.Default)), Output: Fragment("a", Element("div"))}},
+			}),
+		},
+		{
+			comment: "in a subsection",
+			input:   &SubsectionNode{Name: "rows", Prototype: Fragment("a", Element("p"))},
+		},
+		{
+			comment: "in deferred content",
+			input:   Deferred("slow", Fragment("a", Element("p")), nil),
+		},
+		{
+			comment: "in a named TemplateNode",
+			input:   &TemplateNode{Template: MustCompile(Fragment("a", Element("p")), &bindings.Map{}, &Compact), Name: "embedded"},
+		},
+		{
+			comment: "in a mapped TemplateNode",
+			input: &TemplateNode{
+				Template: MustCompile(Fragment("a", Element("p", Text(bindings.Declare("x", safe.Default)))), &bindings.Map{}, &Compact),
+				Vars:     map[string]string{"x": "y"},
+			},
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			if _, err := Compile(tc.input, &bindings.Map{}, &Compact); err == nil {
+				t.Errorf("Compile(%v) => no error, wanted one", tc.input)
+			}
+		})
+	}
+}
+
+func TestFragmentStaticTemplateNode(t *testing.T) {
+	// A static Template is inlined, so its fragments are the page's.
+	embedded := MustCompile(Fragment("a", Element("p", Text(safe.Const("Hi")))), &bindings.Map{}, &Compact)
+	var m bindings.Map
+	tmpl := MustCompile(Element("body", &TemplateNode{Template: embedded}), &m, &Compact)
+	var sb strings.Builder
+	if err := tmpl.GenerateFragment(&sb, "a", m.MustBind()); err != nil {
+		t.Fatalf("GenerateFragment: %v", err)
+	}
+	if diff := cmp.Diff("<p>Hi</p>", sb.String()); diff != "" {
+		t.Errorf("GenerateFragment() => (-)wanted vs (+)got:\n%s", diff)
+	}
+}
+
+func TestFragmentNested(t *testing.T) {
+	// Every level of nesting used to compile the content below it twice, so
+	// this would never finish.
+	const depth = 40
+	var content Node = Text(bindings.Declare("leaf", safe.Default))
+	for i := depth - 1; i >= 0; i-- {
+		content = Fragment(fmt.Sprintf("f%02d", i), Element("div", content))
+	}
+	var m bindings.Map
+	tmpl := MustCompile(Element("body", content), &m, &Compact)
+	if got := len(tmpl.Fragments()); got != depth {
+		t.Errorf("len(Fragments()) => %d, wanted %d", got, depth)
+	}
+
+	leaf, _ := m.Lookup("leaf")
+	vm := m.MustBind(leaf.BindConst("x"))
+	for _, tc := range []struct {
+		id   string
+		want string
+	}{
+		{id: "f00", want: strings.Repeat("<div>", depth) + "x" + strings.Repeat("</div>", depth)},
+		{id: "f38", want: "<div><div>x</div></div>"},
+		{id: "f39", want: "<div>x</div>"},
+	} {
+		var sb strings.Builder
+		if err := tmpl.GenerateFragment(&sb, tc.id, vm); err != nil {
+			t.Errorf("GenerateFragment(%q): %v", tc.id, err)
+			continue
+		}
+		if diff := cmp.Diff(tc.want, sb.String()); diff != "" {
+			t.Errorf("GenerateFragment(%q) => (-)wanted vs (+)got:\n%s", tc.id, diff)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package httpx
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"io"
+	"net/http"
+)
+
+// Default names of the CSRF cookie and form field.
+const (
+	DefaultCSRFCookie = "csrf"
+	DefaultCSRFField  = "_csrf"
+	// CSRFHeader carries the token for requests that aren't form submissions,
+	// such as from JavaScript.
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// Sizes of the random values in the cookie and the tokens.
+const (
+	csrfSecretSize = 32
+	csrfNonceSize  = 16
+)
+
+// CSRF is middleware that protects against cross-site request forgery.
+//
+// Every client gets a random secret in a cookie. Every request gets a new
+// token, derived from the secret, which Handler passes to the Template as
+// html5.RenderOptions.CSRFToken. Templates compiled with
+// html5.CompileOptions.CSRFField include the token in all their POST forms.
+// Requests with unsafe methods, such as POST, are rejected with 403
+// Forbidden, unless they carry a token derived from their cookie, either in
+// the form field or in the CSRFHeader.
+//
+// Tokens are an HMAC of the secret and a per-token nonce, so they're different
+// every time, even though the secret stays the same.
+type CSRF struct {
+	// Key signs the tokens. It must be secret, and should be at least 32
+	// bytes. Required.
+	Key []byte
+	// Cookie is the name of the cookie with the secret. If empty,
+	// DefaultCSRFCookie is used.
+	Cookie string
+	// Field is the name of the form field with the token. It must match
+	// html5.CompileOptions.CSRFField. If empty, DefaultCSRFField is used.
+	Field string
+	// Insecure, if true, allows the cookie to be sent over plain HTTP. Only
+	// for local development.
+	Insecure bool
+	// Rand is the source of the secrets and nonces. If nil, crypto/rand is
+	// used.
+	Rand io.Reader
+}
+
+type csrfContextKey struct{}
+
+// csrfValues are what the middleware passes on with the request.
+type csrfValues struct {
+	token string
+	// secretID stands for the client's secret in ETags, so that pages cached
+	// with tokens for an old secret don't match.
+	secretID string
+}
+
+// CSRFToken returns the CSRF token of the request, or "" if the request didn't
+// go through the CSRF middleware.
+func CSRFToken(r *http.Request) string {
+	values, _ := r.Context().Value(csrfContextKey{}).(csrfValues)
+	return values.token
+}
+
+// csrfSecretID returns the ID of the request's CSRF secret, or "" if the
+// request didn't go through the CSRF middleware.
+func csrfSecretID(r *http.Request) string {
+	values, _ := r.Context().Value(csrfContextKey{}).(csrfValues)
+	return values.secretID
+}
+
+// Wrap returns a handler that checks the CSRF token of unsafe requests, and
+// passes the request on to next with a new token.
+func (c *CSRF) Wrap(next http.Handler) http.Handler {
+	if len(c.Key) == 0 {
+		panic("CSRF.Key is required")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		secret := c.secret(r)
+		if !safeMethod(r.Method) && (secret == nil || !c.verify(secret, c.submittedToken(r))) {
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+
+		if secret == nil {
+			var err error
+			if secret, err = c.random(csrfSecretSize); err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			http.SetCookie(w, &http.Cookie{
+				Name:     c.cookieName(),
+				Value:    base64.RawURLEncoding.EncodeToString(secret),
+				Path:     "/",
+				HttpOnly: true,
+				Secure:   !c.Insecure,
+				SameSite: http.SameSiteLaxMode,
+			})
+		}
+		token, err := c.token(secret)
+		if err != nil {
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		values := csrfValues{token: token, secretID: c.secretID(secret)}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfContextKey{}, values)))
+	})
+}
+
+func safeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	default:
+		return false
+	}
+}
+
+func (c *CSRF) cookieName() string {
+	if c.Cookie == "" {
+		return DefaultCSRFCookie
+	}
+	return c.Cookie
+}
+
+func (c *CSRF) fieldName() string {
+	if c.Field == "" {
+		return DefaultCSRFField
+	}
+	return c.Field
+}
+
+func (c *CSRF) random(n int) ([]byte, error) {
+	r := c.Rand
+	if r == nil {
+		r = rand.Reader
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// secret returns the secret from the request's cookie, or nil if there's no
+// valid one.
+func (c *CSRF) secret(r *http.Request) []byte {
+	cookie, err := r.Cookie(c.cookieName())
+	if err != nil {
+		return nil
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(cookie.Value)
+	if err != nil || len(secret) != csrfSecretSize {
+		return nil
+	}
+	return secret
+}
+
+func (c *CSRF) submittedToken(r *http.Request) string {
+	if token := r.Header.Get(CSRFHeader); token != "" {
+		return token
+	}
+	return r.PostFormValue(c.fieldName())
+}
+
+// token returns a new token for the secret: a random nonce, followed by the
+// nonce's signature.
+func (c *CSRF) token(secret []byte) (string, error) {
+	nonce, err := c.random(csrfNonceSize)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(append(nonce, c.sign(secret, nonce)...)), nil
+}
+
+// secretID returns a signature of the secret, which doesn't reveal it. The
+// label is shorter than a nonce, so the ID can't pass for a token.
+func (c *CSRF) secretID(secret []byte) string {
+	return base64.RawURLEncoding.EncodeToString(c.sign(secret, []byte("etag"))[:16])
+}
+
+func (c *CSRF) verify(secret []byte, token string) bool {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) != csrfNonceSize+sha256.Size {
+		return false
+	}
+	return hmac.Equal(b[csrfNonceSize:], c.sign(secret, b[:csrfNonceSize]))
+}
+
+func (c *CSRF) sign(secret, nonce []byte) []byte {
+	mac := hmac.New(sha256.New, c.Key)
+	mac.Write(secret)
+	mac.Write(nonce)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,188 @@
+package httpx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/the80srobot/html5"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// countingReader returns the bytes 0, 1, 2... wrapping at 256.
+type countingReader struct {
+	n byte
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = cr.n
+		cr.n++
+	}
+	return len(p), nil
+}
+
+var tokenInput = regexp.MustCompile(`<input type="hidden" name="_csrf" value="([^"]+)">`)
+
+func TestCSRF(t *testing.T) {
+	tmpl := html5.MustCompile(html5.Element("form",
+		html5.Attribute("method", safe.Const("post")),
+		html5.Element("button", html5.Text(safe.Const("Go")))),
+		&bindings.Map{}, &html5.CompileOptions{Compact: true, CSRFField: DefaultCSRFField})
+	csrf := &CSRF{Key: []byte("test key"), Rand: &countingReader{}}
+	h := csrf.Wrap(&Handler{Template: tmpl})
+
+	// The first request gets the secret and a token.
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET => status %d, wanted 200", resp.StatusCode)
+	}
+	cookies := resp.Cookies()
+	if len(cookies) != 1 || cookies[0].Name != DefaultCSRFCookie || !cookies[0].HttpOnly || !cookies[0].Secure {
+		t.Fatalf("GET => cookies %v, wanted a secure, HTTP-only %s cookie", cookies, DefaultCSRFCookie)
+	}
+	m := tokenInput.FindStringSubmatch(w.Body.String())
+	if m == nil {
+		t.Fatalf("GET => body %q, wanted a hidden CSRF input", w.Body.String())
+	}
+	token := m[1]
+
+	// Tokens are different every time.
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("GET", "/", nil)
+	r.AddCookie(cookies[0])
+	h.ServeHTTP(w, r)
+	if len(w.Result().Cookies()) != 0 {
+		t.Errorf("GET with a cookie => a new cookie, wanted none")
+	}
+	if m := tokenInput.FindStringSubmatch(w.Body.String()); m == nil || m[1] == token {
+		t.Errorf("GET with a cookie => body %q, wanted a new token", w.Body.String())
+	}
+
+	otherCookie := &http.Cookie{Name: DefaultCSRFCookie, Value: strings.Repeat("A", 43)}
+	for _, tc := range []struct {
+		comment    string
+		cookie     *http.Cookie
+		field      string
+		header     string
+		wantStatus int
+	}{
+		{comment: "field", cookie: cookies[0], field: token, wantStatus: http.StatusOK},
+		{comment: "header", cookie: cookies[0], header: token, wantStatus: http.StatusOK},
+		{comment: "no token", cookie: cookies[0], wantStatus: http.StatusForbidden},
+		{comment: "no cookie", field: token, wantStatus: http.StatusForbidden},
+		{comment: "other cookie", cookie: otherCookie, field: token, wantStatus: http.StatusForbidden},
+		{comment: "bad token", cookie: cookies[0], field: token[1:], wantStatus: http.StatusForbidden},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			form := url.Values{}
+			if tc.field != "" {
+				form.Set(DefaultCSRFField, tc.field)
+			}
+			r := httptest.NewRequest("POST", "/", strings.NewReader(form.Encode()))
+			r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			if tc.header != "" {
+				r.Header.Set(CSRFHeader, tc.header)
+			}
+			if tc.cookie != nil {
+				r.AddCookie(tc.cookie)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+			if w.Code != tc.wantStatus {
+				t.Errorf("POST => status %d, wanted %d", w.Code, tc.wantStatus)
+			}
+		})
+	}
+}
+
+func TestCSRFSubsection(t *testing.T) {
+	// Each row has its own form, rendered with the row's ValueMap, which
+	// doesn't hold the token.
+	var m bindings.Map
+	tmpl := html5.MustCompile(html5.Element("ul", &html5.SubsectionNode{
+		Name: "items",
+		Prototype: html5.Element("li", html5.Element("form",
+			html5.Attribute("method", safe.Const("post")),
+			html5.Element("button", html5.Text(bindings.Declare("item", safe.Default))))),
+	}), &m, &html5.CompileOptions{Compact: true, CSRFField: DefaultCSRFField})
+	items := m.Nest("items")
+	item, _ := items.Lookup("item")
+	csrf := &CSRF{Key: []byte("test key"), Rand: &countingReader{}}
+	h := csrf.Wrap(&Handler{
+		Template: tmpl,
+		Bind: func(r *http.Request, vm *bindings.ValueMap) error {
+			return vm.Set(items.BindSeries(
+				items.MustBind(item.BindConst("Delete a")),
+				items.MustBind(item.BindConst("Delete b"))))
+		},
+		Options: func(*http.Request) *html5.RenderOptions { return &html5.RenderOptions{Validate: true} },
+	})
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	cookies := w.Result().Cookies()
+	matches := tokenInput.FindAllStringSubmatch(w.Body.String(), -1)
+	if len(matches) != 2 || len(cookies) != 1 {
+		t.Fatalf("GET => body %q, cookies %v, wanted two hidden CSRF inputs and a cookie", w.Body.String(), cookies)
+	}
+
+	// Submitting any of the forms passes the check.
+	for i, m := range matches {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(url.Values{DefaultCSRFField: {m[1]}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		r.AddCookie(cookies[0])
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusOK {
+			t.Errorf("POST form %d => status %d, wanted %d", i, w.Code, http.StatusOK)
+		}
+	}
+}
+
+func TestCSRFETag(t *testing.T) {
+	tmpl := html5.MustCompile(html5.Element("form",
+		html5.Attribute("method", safe.Const("post")),
+		html5.Element("button", html5.Text(safe.Const("Go")))),
+		&bindings.Map{}, &html5.CompileOptions{Compact: true, CSRFField: DefaultCSRFField})
+	csrf := &CSRF{Key: []byte("test key"), Rand: &countingReader{}}
+	h := csrf.Wrap(&Handler{Template: tmpl, ETag: true})
+	get := func(cookie *http.Cookie, ifNoneMatch string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("GET", "/", nil)
+		if cookie != nil {
+			r.AddCookie(cookie)
+		}
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	first := get(nil, "")
+	cookies := first.Result().Cookies()
+	tag := first.Header().Get("ETag")
+	if len(cookies) != 1 || tag == "" {
+		t.Fatalf("GET => cookies %v, ETag %q, wanted a cookie and an ETag", cookies, tag)
+	}
+
+	// The same secret can reuse the cached page and its token.
+	if w := get(cookies[0], tag); w.Code != http.StatusNotModified {
+		t.Errorf("GET with the same secret => status %d, wanted %d", w.Code, http.StatusNotModified)
+	}
+	// A new secret, like after a browser restart, needs new tokens.
+	w := get(nil, tag)
+	if w.Code != http.StatusOK {
+		t.Errorf("GET with a new secret => status %d, wanted %d", w.Code, http.StatusOK)
+	}
+	if w.Header().Get("ETag") == tag {
+		t.Errorf("GET with a new secret => the same ETag %q, wanted a new one", tag)
+	}
+}
@@ -0,0 +1,83 @@
+package httpx
+
+import (
+	"compress/gzip"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Encoder is a content coding, like gzip, that the Handler can negotiate with
+// the Accept-Encoding request header.
+//
+// The standard library has no brotli implementation, but one can be plugged
+// in:
+//
+//	httpx.Encoder{Name: "br", NewWriter: func(w io.Writer) io.WriteCloser {
+//		return brotli.NewWriter(w)
+//	}}
+type Encoder struct {
+	// Name is the token in the Accept-Encoding and Content-Encoding headers.
+	Name string
+	// NewWriter returns a writer that encodes to w. Close is called at the end
+	// of the response, and must flush everything to w.
+	NewWriter func(w io.Writer) io.WriteCloser
+}
+
+// Gzip encodes responses with compress/gzip, at the default compression level.
+var Gzip = Encoder{
+	Name: "gzip",
+	NewWriter: func(w io.Writer) io.WriteCloser {
+		return gzip.NewWriter(w)
+	},
+}
+
+// DefaultEncoders are used by Handlers that don't specify any.
+var DefaultEncoders = []Encoder{Gzip}
+
+// negotiate returns the first of the encoders that the Accept-Encoding header
+// accepts, or nil if none is accepted (or the header is missing).
+func negotiate(acceptEncoding string, encoders []Encoder) *Encoder {
+	if acceptEncoding == "" || len(encoders) == 0 {
+		return nil
+	}
+
+	accepted := map[string]float64{}
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, q := parseCoding(part)
+		if name != "" {
+			accepted[name] = q
+		}
+	}
+
+	for i := range encoders {
+		q, ok := accepted[encoders[i].Name]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > 0 {
+			return &encoders[i]
+		}
+	}
+	return nil
+}
+
+// parseCoding parses one element of the Accept-Encoding header, like
+// "gzip;q=0.8". Malformed quality values count as zero.
+func parseCoding(s string) (string, float64) {
+	params := strings.Split(s, ";")
+	name := strings.ToLower(strings.TrimSpace(params[0]))
+	q := 1.0
+	for _, p := range params[1:] {
+		p = strings.TrimSpace(p)
+		if !strings.HasPrefix(p, "q=") && !strings.HasPrefix(p, "Q=") {
+			continue
+		}
+		f, err := strconv.ParseFloat(p[2:], 64)
+		if err != nil {
+			f = 0
+		}
+		q = f
+	}
+	return name, q
+}
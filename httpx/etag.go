@@ -0,0 +1,24 @@
+package httpx
+
+import "strings"
+
+// etag returns the ETag header for a fingerprint. Each content coding is a
+// different representation of the page, so it gets a different ETag.
+func etag(fingerprint string, encoder *Encoder) string {
+	if encoder != nil {
+		return `"` + fingerprint + "-" + encoder.Name + `"`
+	}
+	return `"` + fingerprint + `"`
+}
+
+// etagMatch reports whether the If-None-Match header matches the ETag. As
+// required for If-None-Match, weak validators match strong ones.
+func etagMatch(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,294 @@
+// Package httpx serves html5 Templates over HTTP.
+//
+// A Handler binds values for each request, renders the Template and takes
+// care of the details every page needs: the Content-Type header, compression,
+// and what to do when rendering fails half way through the page.
+package httpx
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/the80srobot/html5"
+	"github.com/the80srobot/html5/bindings"
+)
+
+// Binder sets the values for a request on the ValueMap.
+type Binder func(r *http.Request, vm *bindings.ValueMap) error
+
+// DefaultBufferSize is the BufferSize of Handlers that don't specify one.
+const DefaultBufferSize = 32 << 10
+
+// Handler is an http.Handler that renders a Template.
+//
+// The response is buffered until it grows past BufferSize, or a
+// html5.FlushPoint is reached. Errors before that are reported with a 500
+// response (see Error), and responses that fit entirely in the buffer get a
+// Content-Length header. Larger responses are streamed, and if rendering fails
+// after streaming started, the connection is aborted, so that the client
+// doesn't mistake a partial page for a complete one.
+type Handler struct {
+	// Template is rendered for every request. Required.
+	Template *html5.Template
+	// Bind sets the values for the request. Optional, if the Template has no
+	// Vars, or can render with defaults.
+	Bind Binder
+	// Options returns the RenderOptions for the request. If nil, the defaults
+	// are used.
+	Options func(r *http.Request) *html5.RenderOptions
+	// BufferSize is the number of bytes buffered before the response is
+	// committed. If zero, DefaultBufferSize is used. If negative, nothing is
+	// buffered.
+	BufferSize int
+	// Encoders are the content codings offered to clients, in order of
+	// preference. If nil, DefaultEncoders are used. Set to an empty slice to
+	// disable compression.
+	Encoders []Encoder
+	// Error writes the response when binding or rendering fails before the
+	// response is committed. If nil, a plain 500 Internal Server Error is
+	// written.
+	Error func(w http.ResponseWriter, r *http.Request, err error)
+	// ETag, if true, sets the ETag header to the Template's fingerprint, and
+	// answers GET and HEAD requests with a matching If-None-Match with 304 Not
+	// Modified, without rendering. Only use it if the page is fully
+	// determined by the bound values. (See html5.Template.Fingerprint.)
+	//
+	// Templates with deferred content never get an ETag: the fingerprint
+	// would wait for all of the content before sending any of the page.
+	// Behind CSRF, the ETag also depends on the client's secret, so a page
+	// cached with tokens for an old secret doesn't match.
+	ETag bool
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	vm, err := h.Template.Bindings.Bind()
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+	if h.Bind != nil {
+		if err := h.Bind(r, vm); err != nil {
+			h.error(w, r, err)
+			return
+		}
+	}
+
+	opts := &html5.RenderOptions{}
+	if h.Options != nil {
+		opts = h.Options(r)
+	}
+	if token := CSRFToken(r); token != "" {
+		// Options may return the same RenderOptions for every request.
+		withToken := *opts
+		withToken.CSRFToken = token
+		opts = &withToken
+	}
+
+	rw := h.newResponseWriter(w, r)
+	if h.ETag && !h.Template.Deferred() {
+		// An invalid ValueMap must fail, not match a cached page.
+		if opts.Validate {
+			if err := vm.Validate(); err != nil {
+				h.error(w, r, err)
+				return
+			}
+		}
+		// The fingerprint only sees the ValueMap, so it needs the locale the
+		// page renders in.
+		if opts.Locale != "" {
+			vm.Locale = opts.Locale
+		}
+		fingerprint := h.Template.Fingerprint(vm)
+		// The page's CSRF tokens are only valid for the client's current
+		// secret, even though the fingerprint doesn't include them.
+		if id := csrfSecretID(r); id != "" {
+			fingerprint += "-" + id
+		}
+		rw.etag = etag(fingerprint, rw.encoder)
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && etagMatch(r.Header.Get("If-None-Match"), rw.etag) {
+			rw.notModified()
+			return
+		}
+	}
+
+	if err := h.Template.Render(rw, vm, opts); err != nil {
+		if rw.committed {
+			// The status line is gone, and the client has part of the page.
+			// The only way to tell it something went wrong is to cut the
+			// connection.
+			panic(http.ErrAbortHandler)
+		}
+		h.error(w, r, err)
+		return
+	}
+	if err := rw.finish(); err != nil {
+		// The client most likely went away.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+func (h *Handler) error(w http.ResponseWriter, r *http.Request, err error) {
+	if h.Error != nil {
+		h.Error(w, r, err)
+		return
+	}
+	http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+}
+
+func (h *Handler) newResponseWriter(w http.ResponseWriter, r *http.Request) *responseWriter {
+	encoders := h.Encoders
+	if encoders == nil {
+		encoders = DefaultEncoders
+	}
+	limit := h.BufferSize
+	if limit == 0 {
+		limit = DefaultBufferSize
+	}
+
+	return &responseWriter{
+		w:       w,
+		limit:   limit,
+		encoder: negotiate(r.Header.Get("Accept-Encoding"), encoders),
+		vary:    len(encoders) != 0,
+	}
+}
+
+// responseWriter buffers the response until it grows past the limit, and then
+// commits it and streams the rest.
+type responseWriter struct {
+	w       http.ResponseWriter
+	limit   int
+	encoder *Encoder
+	// Whether the response depends on Accept-Encoding.
+	vary bool
+	// The ETag header, if any.
+	etag string
+
+	buf       bytes.Buffer
+	committed bool
+	// The encoder's writer, or w if there's no encoder. Only set once
+	// committed.
+	out        io.Writer
+	encoderOut io.WriteCloser
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if !rw.committed {
+		if rw.buf.Len()+len(p) <= rw.limit {
+			return rw.buf.Write(p)
+		}
+		if err := rw.commit(); err != nil {
+			return 0, err
+		}
+	}
+	return rw.out.Write(p)
+}
+
+func (rw *responseWriter) WriteString(s string) (int, error) {
+	if !rw.committed {
+		if rw.buf.Len()+len(s) <= rw.limit {
+			return rw.buf.WriteString(s)
+		}
+		if err := rw.commit(); err != nil {
+			return 0, err
+		}
+	}
+	return io.WriteString(rw.out, s)
+}
+
+// writeHeader sets the headers of a successful response, and writes them. The
+// headers aren't set any earlier, because an error response needs different
+// ones. If contentLength is negative, then it's unknown.
+func (rw *responseWriter) writeHeader(contentLength int) {
+	rw.committed = true
+	header := rw.w.Header()
+	if header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "text/html; charset=utf-8")
+	}
+	rw.setCacheHeaders()
+	if rw.encoder != nil {
+		header.Set("Content-Encoding", rw.encoder.Name)
+	}
+	if contentLength >= 0 {
+		header.Set("Content-Length", strconv.Itoa(contentLength))
+	}
+	rw.w.WriteHeader(http.StatusOK)
+}
+
+// setCacheHeaders sets the headers that a 304 Not Modified response must repeat.
+func (rw *responseWriter) setCacheHeaders() {
+	header := rw.w.Header()
+	if rw.vary {
+		header.Add("Vary", "Accept-Encoding")
+	}
+	if rw.etag != "" {
+		header.Set("ETag", rw.etag)
+	}
+}
+
+// notModified writes a 304 Not Modified response.
+func (rw *responseWriter) notModified() {
+	rw.committed = true
+	rw.setCacheHeaders()
+	rw.w.WriteHeader(http.StatusNotModified)
+}
+
+// commit writes the headers and the buffered data, and switches to streaming.
+func (rw *responseWriter) commit() error {
+	rw.writeHeader(-1)
+	rw.out = rw.w
+	if rw.encoder != nil {
+		rw.encoderOut = rw.encoder.NewWriter(rw.w)
+		rw.out = rw.encoderOut
+	}
+	_, err := rw.buf.WriteTo(rw.out)
+	return err
+}
+
+// finish completes the response. If it's still buffered, then it's written
+// with a Content-Length.
+func (rw *responseWriter) finish() error {
+	if rw.committed {
+		if rw.encoderOut != nil {
+			return rw.encoderOut.Close()
+		}
+		return nil
+	}
+
+	body := &rw.buf
+	if rw.encoder != nil {
+		var encoded bytes.Buffer
+		ew := rw.encoder.NewWriter(&encoded)
+		if _, err := rw.buf.WriteTo(ew); err != nil {
+			return err
+		}
+		if err := ew.Close(); err != nil {
+			return err
+		}
+		body = &encoded
+	}
+	rw.writeHeader(body.Len())
+	_, err := body.WriteTo(rw.w)
+	return err
+}
+
+// Flush commits the response, if it's still buffered, and sends everything
+// written so far to the client. Templates call it at every html5.FlushPoint.
+func (rw *responseWriter) Flush() error {
+	if !rw.committed {
+		if err := rw.commit(); err != nil {
+			return err
+		}
+	}
+	if f, ok := rw.encoderOut.(interface{ Flush() error }); ok {
+		if err := f.Flush(); err != nil {
+			return err
+		}
+	}
+	if f, ok := rw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
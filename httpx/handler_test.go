@@ -0,0 +1,410 @@
+package httpx
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// testPage returns a Template with a title and a list of items, and a Binder
+// that sets the title from the "title" query parameter, and the given number
+// of items.
+func testPage(t *testing.T, items int) (*html5.Template, Binder) {
+	t.Helper()
+	var m bindings.Map
+	tmpl := html5.MustCompile(html5.Element("body",
+		html5.Element("h1", html5.Text(bindings.Declare("title", safe.Default))),
+		&html5.SubsectionNode{Name: "items", Prototype: html5.Element("p", html5.Text(bindings.Declare("item", safe.Default)))}),
+		&m, &html5.Compact)
+
+	title, _ := m.Lookup("title")
+	nested := m.Nest("items")
+	item, _ := nested.Lookup("item")
+	bind := func(r *http.Request, vm *bindings.ValueMap) error {
+		if r.URL.Query().Get("fail") != "" {
+			return errors.New("binding failed")
+		}
+		if s := r.URL.Query().Get("title"); s != "" {
+			if err := vm.Set(title.Bind(safe.EscapeText(s))); err != nil {
+				return err
+			}
+		}
+		var series bindings.ValueSeries
+		for i := 0; i < items; i++ {
+			series = append(series, nested.MustBind(item.Bind(safe.EscapeText(strconv.Itoa(i)))))
+		}
+		return vm.Set(nested.BindSeries(series...))
+	}
+	return tmpl, bind
+}
+
+func wantPage(title string, items int) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "<body><h1>%s</h1>", title)
+	for i := 0; i < items; i++ {
+		fmt.Fprintf(&sb, "<p>%d</p>", i)
+	}
+	sb.WriteString("</body>")
+	return sb.String()
+}
+
+func TestHandler(t *testing.T) {
+	tmpl, bind := testPage(t, 100)
+	for _, tc := range []struct {
+		comment        string
+		handler        *Handler
+		url            string
+		acceptEncoding string
+		wantStatus     int
+		wantBody       string
+		wantHeader     http.Header
+	}{
+		{
+			comment:    "buffered",
+			handler:    &Handler{Template: tmpl, Bind: bind},
+			url:        "/?title=Hello",
+			wantStatus: http.StatusOK,
+			wantBody:   wantPage("Hello", 100),
+			wantHeader: http.Header{
+				"Content-Type":   {"text/html; charset=utf-8"},
+				"Content-Length": {strconv.Itoa(len(wantPage("Hello", 100)))},
+				"Vary":           {"Accept-Encoding"},
+			},
+		},
+		{
+			comment:        "gzip",
+			handler:        &Handler{Template: tmpl, Bind: bind},
+			url:            "/?title=Hello",
+			acceptEncoding: "br;q=1.0, gzip;q=0.5",
+			wantStatus:     http.StatusOK,
+			wantBody:       wantPage("Hello", 100),
+			wantHeader: http.Header{
+				"Content-Type":     {"text/html; charset=utf-8"},
+				"Content-Encoding": {"gzip"},
+				"Vary":             {"Accept-Encoding"},
+			},
+		},
+		{
+			comment:        "streamed",
+			handler:        &Handler{Template: tmpl, Bind: bind, BufferSize: 64, Encoders: []Encoder{}},
+			url:            "/?title=Hello",
+			acceptEncoding: "gzip",
+			wantStatus:     http.StatusOK,
+			wantBody:       wantPage("Hello", 100),
+			wantHeader: http.Header{
+				"Content-Type": {"text/html; charset=utf-8"},
+			},
+		},
+		{
+			comment:        "streamed gzip",
+			handler:        &Handler{Template: tmpl, Bind: bind, BufferSize: -1},
+			url:            "/?title=Hello",
+			acceptEncoding: "gzip",
+			wantStatus:     http.StatusOK,
+			wantBody:       wantPage("Hello", 100),
+			wantHeader: http.Header{
+				"Content-Type":     {"text/html; charset=utf-8"},
+				"Content-Encoding": {"gzip"},
+				"Vary":             {"Accept-Encoding"},
+			},
+		},
+		{
+			comment:        "bind error",
+			handler:        &Handler{Template: tmpl, Bind: bind},
+			url:            "/?fail=1",
+			acceptEncoding: "gzip",
+			wantStatus:     http.StatusInternalServerError,
+			wantBody:       "Internal Server Error\n",
+			wantHeader: http.Header{
+				"Content-Type":           {"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": {"nosniff"},
+			},
+		},
+		{
+			comment: "render error before commit",
+			handler: &Handler{
+				Template: tmpl,
+				Bind:     bind,
+				Options:  func(*http.Request) *html5.RenderOptions { return &html5.RenderOptions{Strict: true} },
+				Error: func(w http.ResponseWriter, r *http.Request, err error) {
+					http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				},
+			},
+			url:        "/",
+			wantStatus: http.StatusServiceUnavailable,
+			wantBody:   "building chunk #1 of 5: unset var title\n",
+			wantHeader: http.Header{
+				"Content-Type":           {"text/plain; charset=utf-8"},
+				"X-Content-Type-Options": {"nosniff"},
+			},
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			r := httptest.NewRequest("GET", tc.url, nil)
+			if tc.acceptEncoding != "" {
+				r.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			}
+			w := httptest.NewRecorder()
+			tc.handler.ServeHTTP(w, r)
+
+			resp := w.Result()
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("ServeHTTP(%q) => status %d, wanted %d", tc.url, resp.StatusCode, tc.wantStatus)
+			}
+			body := resp.Body
+			if resp.Header.Get("Content-Encoding") == "gzip" {
+				gz, err := gzip.NewReader(resp.Body)
+				if err != nil {
+					t.Fatalf("gzip.NewReader: %v", err)
+				}
+				body = gz
+				tc.wantHeader.Set("Content-Length", strconv.Itoa(w.Body.Len()))
+				if tc.handler.BufferSize < 0 {
+					tc.wantHeader.Del("Content-Length")
+				}
+			}
+			b, err := ioutil.ReadAll(body)
+			if err != nil {
+				t.Fatalf("reading the body: %v", err)
+			}
+			if diff := cmp.Diff(tc.wantBody, string(b)); diff != "" {
+				t.Errorf("ServeHTTP(%q) => (-)wanted vs (+)got body:\n%s", tc.url, diff)
+			}
+			if diff := cmp.Diff(tc.wantHeader, resp.Header); diff != "" {
+				t.Errorf("ServeHTTP(%q) => (-)wanted vs (+)got headers:\n%s", tc.url, diff)
+			}
+		})
+	}
+}
+
+func TestHandlerAbort(t *testing.T) {
+	// The title is missing, but that's only noticed after the response is
+	// committed.
+	tmpl := html5.MustCompile(html5.Element("body",
+		html5.Text(safe.Const("A long static introduction to the page.")),
+		html5.Text(bindings.Declare("title", safe.Default))), &bindings.Map{}, &html5.Compact)
+	h := &Handler{
+		Template:   tmpl,
+		BufferSize: 16,
+		Options:    func(*http.Request) *html5.RenderOptions { return &html5.RenderOptions{Strict: true} },
+	}
+
+	w := httptest.NewRecorder()
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("ServeHTTP with an error after commit => panic(%v), wanted panic(http.ErrAbortHandler)", r)
+		}
+		if w.Code != http.StatusOK {
+			t.Errorf("ServeHTTP with an error after commit => status %d, wanted the committed %d", w.Code, http.StatusOK)
+		}
+	}()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+}
+
+func TestNegotiate(t *testing.T) {
+	br := Encoder{Name: "br"}
+	encoders := []Encoder{br, Gzip}
+	for _, tc := range []struct {
+		acceptEncoding string
+		want           string
+	}{
+		{acceptEncoding: "", want: ""},
+		{acceptEncoding: "gzip", want: "gzip"},
+		{acceptEncoding: "gzip, br", want: "br"},
+		{acceptEncoding: "GZIP;q=0.1", want: "gzip"},
+		{acceptEncoding: "br;q=0, gzip", want: "gzip"},
+		{acceptEncoding: "*", want: "br"},
+		{acceptEncoding: "*;q=0", want: ""},
+		{acceptEncoding: "br;q=0, *", want: "gzip"},
+		{acceptEncoding: "deflate, identity", want: ""},
+		{acceptEncoding: "gzip;q=nope", want: ""},
+	} {
+		got := ""
+		if e := negotiate(tc.acceptEncoding, encoders); e != nil {
+			got = e.Name
+		}
+		if got != tc.want {
+			t.Errorf("negotiate(%q) => %q, wanted %q", tc.acceptEncoding, got, tc.want)
+		}
+	}
+}
+
+func TestHandlerFlush(t *testing.T) {
+	var m bindings.Map
+	tmpl := html5.MustCompile(html5.Element("html",
+		html5.Element("head", html5.Element("title", html5.Text(safe.Const("Hi")))),
+		html5.Element("body", html5.Text(bindings.Declare("body", safe.Default)))),
+		&m, &html5.CompileOptions{Compact: true, FlushAfterHead: true})
+	body, _ := m.Lookup("body")
+
+	for _, acceptEncoding := range []string{"", "gzip"} {
+		var atFlush string
+		h := &Handler{
+			Template: tmpl,
+			Bind: func(r *http.Request, vm *bindings.ValueMap) error {
+				return vm.Set(body.BindConst("Hello"))
+			},
+		}
+		w := &flushRecorder{ResponseRecorder: httptest.NewRecorder(), atFlush: &atFlush}
+		r := httptest.NewRequest("GET", "/", nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		h.ServeHTTP(w, r)
+
+		if !w.Flushed {
+			t.Errorf("ServeHTTP with Accept-Encoding %q didn't flush", acceptEncoding)
+		}
+		if got := w.Header().Get("Content-Length"); got != "" {
+			t.Errorf("ServeHTTP with Accept-Encoding %q => Content-Length %s, wanted none after a flush", acceptEncoding, got)
+		}
+
+		read := func(s string) string {
+			if acceptEncoding != "gzip" {
+				return s
+			}
+			gz, err := gzip.NewReader(strings.NewReader(s))
+			if err != nil {
+				t.Fatalf("gzip.NewReader: %v", err)
+			}
+			// The stream isn't finished at the flush, so ignore the error.
+			b, _ := ioutil.ReadAll(gz)
+			return string(b)
+		}
+		if diff := cmp.Diff("<html><head><title>Hi</title></head>", read(atFlush)); diff != "" {
+			t.Errorf("ServeHTTP with Accept-Encoding %q => (-)wanted vs (+)got at flush:\n%s", acceptEncoding, diff)
+		}
+		if diff := cmp.Diff("<html><head><title>Hi</title></head><body>Hello</body></html>", read(w.Body.String())); diff != "" {
+			t.Errorf("ServeHTTP with Accept-Encoding %q => (-)wanted vs (+)got body:\n%s", acceptEncoding, diff)
+		}
+	}
+}
+
+// flushRecorder records the body at the first flush.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	atFlush *string
+}
+
+func (fr *flushRecorder) Flush() {
+	if !fr.Flushed {
+		*fr.atFlush = fr.Body.String()
+	}
+	fr.ResponseRecorder.Flush()
+}
+
+func TestHandlerETag(t *testing.T) {
+	tmpl, bind := testPage(t, 3)
+	h := &Handler{Template: tmpl, Bind: bind, ETag: true}
+	get := func(url, acceptEncoding, ifNoneMatch string) *http.Response {
+		r := httptest.NewRequest("GET", url, nil)
+		if acceptEncoding != "" {
+			r.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		if ifNoneMatch != "" {
+			r.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Result()
+	}
+
+	first := get("/?title=Hello", "", "")
+	tag := first.Header.Get("ETag")
+	if first.StatusCode != http.StatusOK || tag == "" {
+		t.Fatalf("ServeHTTP() => status %d, ETag %q, wanted 200 and an ETag", first.StatusCode, tag)
+	}
+
+	for _, tc := range []struct {
+		comment        string
+		url            string
+		acceptEncoding string
+		ifNoneMatch    string
+		wantStatus     int
+	}{
+		{comment: "match", url: "/?title=Hello", ifNoneMatch: tag, wantStatus: http.StatusNotModified},
+		{comment: "weak match in a list", url: "/?title=Hello", ifNoneMatch: `"nope", W/` + tag, wantStatus: http.StatusNotModified},
+		{comment: "wildcard", url: "/?title=Hello", ifNoneMatch: "*", wantStatus: http.StatusNotModified},
+		{comment: "different values", url: "/?title=Bye", ifNoneMatch: tag, wantStatus: http.StatusOK},
+		{comment: "different encoding", url: "/?title=Hello", acceptEncoding: "gzip", ifNoneMatch: tag, wantStatus: http.StatusOK},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			resp := get(tc.url, tc.acceptEncoding, tc.ifNoneMatch)
+			if resp.StatusCode != tc.wantStatus {
+				t.Errorf("ServeHTTP(%q) with If-None-Match %q => status %d, wanted %d", tc.url, tc.ifNoneMatch, resp.StatusCode, tc.wantStatus)
+			}
+			if resp.Header.Get("ETag") == "" {
+				t.Errorf("ServeHTTP(%q) with If-None-Match %q => no ETag", tc.url, tc.ifNoneMatch)
+			}
+			if resp.StatusCode == http.StatusNotModified {
+				b, _ := ioutil.ReadAll(resp.Body)
+				if len(b) != 0 {
+					t.Errorf("ServeHTTP(%q) => 304 with body %q, wanted none", tc.url, b)
+				}
+				if got := resp.Header.Get("Vary"); got != "Accept-Encoding" {
+					t.Errorf("ServeHTTP(%q) => 304 with Vary %q, wanted Accept-Encoding", tc.url, got)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlerETagValidate(t *testing.T) {
+	tmpl, bind := testPage(t, 3)
+	h := &Handler{
+		Template: tmpl,
+		Bind:     bind,
+		Options:  func(*http.Request) *html5.RenderOptions { return &html5.RenderOptions{Validate: true} },
+		ETag:     true,
+	}
+	// Without a title, the ValueMap is invalid, so even a wildcard mustn't
+	// match.
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if got := w.Result().StatusCode; got != http.StatusInternalServerError {
+		t.Errorf("ServeHTTP() with an unset Var => status %d, wanted %d", got, http.StatusInternalServerError)
+	}
+}
+
+func TestHandlerETagDeferred(t *testing.T) {
+	var m bindings.Map
+	tmpl := html5.MustCompile(html5.Element("body",
+		html5.Deferred("slow", html5.Element("p", html5.Text(bindings.Declare("text", safe.Default))), nil)),
+		&m, &html5.Compact)
+	slow := m.Nest("slow")
+	text, _ := slow.Lookup("text")
+	future := bindings.NewFuture()
+	h := &Handler{
+		Template: tmpl,
+		Bind: func(r *http.Request, vm *bindings.ValueMap) error {
+			return vm.Set(slow.BindStream(future))
+		},
+		ETag: true,
+	}
+	future.Resolve(slow.MustBind(text.BindConst("Hello")))
+
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("If-None-Match", "*")
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	resp := w.Result()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("ServeHTTP() => status %d, wanted %d", resp.StatusCode, http.StatusOK)
+	}
+	if got := resp.Header.Get("ETag"); got != "" {
+		t.Errorf("ServeHTTP() => ETag %q, wanted none for deferred content", got)
+	}
+}
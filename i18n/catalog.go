@@ -0,0 +1,212 @@
+// Package i18n translates and formats values for bindings.ValueMaps.
+//
+// A Catalog holds translated messages keyed by ID, with plural forms selected
+// by CLDR plural rules. Translations are bound to Vars as safe.Text, after
+// placeholders are substituted and the result is escaped, so translators can
+// never inject HTML into the page.
+//
+// The locale is selected per render, through bindings.ValueMap.Locale.
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// ErrNoOther is returned when adding a Message without the Other form, which
+// every locale falls back to.
+var ErrNoOther = errors.New("message has no other form")
+
+// ErrMissingMessage is returned when the Catalog has no translation for a
+// message ID, even in the fallback locale.
+var ErrMissingMessage = errors.New("missing message")
+
+// Message is a single translated message. Other is required. The other forms
+// are only used with counts, for locales whose plural rules select them, and
+// default to Other when empty.
+//
+// Messages may contain placeholders, like "{name}", which are replaced with
+// the value of the Arg with the same name. The placeholder "{count}" is
+// replaced with the count passed to Catalog.Plural.
+type Message struct {
+	Zero, One, Two, Few, Many, Other string
+}
+
+func (m Message) form(p Plural) string {
+	var s string
+	switch p {
+	case Zero:
+		s = m.Zero
+	case One:
+		s = m.One
+	case Two:
+		s = m.Two
+	case Few:
+		s = m.Few
+	case Many:
+		s = m.Many
+	}
+	if s == "" {
+		return m.Other
+	}
+	return s
+}
+
+// Arg is a named value for a placeholder in a Message. Values are formatted
+// according to their type and the locale: int, int64 and float64 as numbers,
+// time.Time as a date, and strings as is.
+type Arg struct {
+	Name  string
+	Value interface{}
+}
+
+// Catalog holds translated Messages by locale and ID. It's safe for concurrent
+// use, and the zero value is an empty Catalog.
+type Catalog struct {
+	// Fallback is the locale used when a message isn't translated to the
+	// requested locale.
+	Fallback string
+
+	mu       sync.RWMutex
+	messages map[string]map[string]Message
+}
+
+// Set adds or replaces the message with the ID in the locale. It returns
+// ErrNoOther if the message has no Other form.
+func (c *Catalog) Set(locale, id string, m Message) error {
+	if m.Other == "" {
+		return fmt.Errorf("%w: %q in locale %q", ErrNoOther, id, locale)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	locale = Normalize(locale)
+	if c.messages == nil {
+		c.messages = map[string]map[string]Message{}
+	}
+	if c.messages[locale] == nil {
+		c.messages[locale] = map[string]Message{}
+	}
+	c.messages[locale][id] = m
+	return nil
+}
+
+// SetText is a shorthand for Set with a Message that has no plural forms.
+func (c *Catalog) SetText(locale, id, text string) error {
+	return c.Set(locale, id, Message{Other: text})
+}
+
+// Locales returns the locales that have at least one message, in order.
+func (c *Catalog) Locales() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	locales := make([]string, 0, len(c.messages))
+	for l := range c.messages {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Lookup returns the message with the ID, trying the locale, then its base
+// language ("pt" for "pt-BR"), then the Fallback locale and its base language.
+func (c *Catalog) Lookup(locale, id string) (Message, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, l := range []string{Normalize(locale), BaseLanguage(locale), Normalize(c.Fallback), BaseLanguage(c.Fallback)} {
+		if m, ok := c.messages[l][id]; ok {
+			return m, true
+		}
+	}
+	return Message{}, false
+}
+
+// Text returns the translation of the message, with placeholders replaced by
+// the args and escaped for use as text.
+func (c *Catalog) Text(locale, id string, args ...Arg) (safe.Text, error) {
+	m, ok := c.Lookup(locale, id)
+	if !ok {
+		return safe.Text{}, fmt.Errorf("%w %q in locale %q", ErrMissingMessage, id, locale)
+	}
+	return safe.EscapeText(substitute(locale, m.Other, args)), nil
+}
+
+// Plural is like Text, but selects the plural form of the message for the
+// count, according to the locale's PluralRule. The count also replaces the
+// "{count}" placeholder.
+func (c *Catalog) Plural(locale, id string, count int64, args ...Arg) (safe.Text, error) {
+	m, ok := c.Lookup(locale, id)
+	if !ok {
+		return safe.Text{}, fmt.Errorf("%w %q in locale %q", ErrMissingMessage, id, locale)
+	}
+	args = append(args, Arg{Name: "count", Value: count})
+	return safe.EscapeText(substitute(locale, m.form(PluralRuleFor(locale)(count)), args)), nil
+}
+
+// BindText sets the Var to the translation of the message in the ValueMap's
+// locale.
+func (c *Catalog) BindText(vm *bindings.ValueMap, v bindings.Var, id string, args ...Arg) error {
+	s, err := c.Text(vm.Locale, id, args...)
+	if err != nil {
+		return err
+	}
+	return vm.Set(v.Bind(s))
+}
+
+// BindPlural sets the Var to the translation of the message in the ValueMap's
+// locale. The plural form is selected by the value of count, which must
+// already be set on the ValueMap with bindings.Var.BindInt.
+func (c *Catalog) BindPlural(vm *bindings.ValueMap, v bindings.Var, id string, count bindings.Var, args ...Arg) error {
+	n, ok := vm.GetInt(count)
+	if !ok {
+		return fmt.Errorf("message %q: count %v is not set to an integer: %w", id, count, bindings.ErrUnset)
+	}
+	s, err := c.Plural(vm.Locale, id, n, args...)
+	if err != nil {
+		return err
+	}
+	return vm.Set(v.Bind(s))
+}
+
+// substitute replaces the placeholders in the message with formatted args.
+// Unknown placeholders are left in place.
+func substitute(locale, msg string, args []Arg) string {
+	if len(args) == 0 || !strings.Contains(msg, "{") {
+		return msg
+	}
+
+	pairs := make([]string, 0, 2*len(args))
+	for _, a := range args {
+		pairs = append(pairs, "{"+a.Name+"}", formatArg(locale, a.Value))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+func formatArg(locale string, v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case int:
+		return FormatInt(locale, int64(v))
+	case int64:
+		return FormatInt(locale, v)
+	case float64:
+		return FormatFloat(locale, v, -1)
+	case time.Time:
+		return FormatDate(locale, v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
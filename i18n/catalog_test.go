@@ -0,0 +1,172 @@
+package i18n
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestPluralRules(t *testing.T) {
+	for _, tc := range []struct {
+		locale string
+		counts []int64
+		want   Plural
+	}{
+		{locale: "en", counts: []int64{1}, want: One},
+		{locale: "en", counts: []int64{0, 2, 11, 101}, want: Other},
+		{locale: "fr", counts: []int64{0, 1}, want: One},
+		{locale: "ru", counts: []int64{1, 21, 101}, want: One},
+		{locale: "ru", counts: []int64{2, 3, 24}, want: Few},
+		{locale: "ru", counts: []int64{0, 5, 11, 12, 14}, want: Many},
+		{locale: "pl", counts: []int64{22}, want: Few},
+		{locale: "pl", counts: []int64{21, 12}, want: Many},
+		{locale: "ar", counts: []int64{0}, want: Zero},
+		{locale: "ar", counts: []int64{2}, want: Two},
+		{locale: "ar", counts: []int64{3, 110}, want: Few},
+		{locale: "ar", counts: []int64{11, 99}, want: Many},
+		{locale: "ar", counts: []int64{100, 102}, want: Other},
+		{locale: "ja", counts: []int64{1}, want: Other},
+		{locale: "pt-BR", counts: []int64{0, 1}, want: One},
+		{locale: "pt", counts: []int64{0, 1}, want: One},
+		{locale: "pt-PT", counts: []int64{1}, want: One},
+		{locale: "pt_PT", counts: []int64{0, 2}, want: Other},
+	} {
+		for _, n := range tc.counts {
+			if got := PluralRuleFor(tc.locale)(n); got != tc.want {
+				t.Errorf("PluralRuleFor(%q)(%d) => %v, wanted %v", tc.locale, n, got, tc.want)
+			}
+		}
+	}
+}
+
+func testCatalog() *Catalog {
+	c := &Catalog{Fallback: "en"}
+	c.SetText("en", "welcome", "Welcome, {name}!")
+	c.SetText("de", "welcome", "Willkommen, {name}!")
+	c.Set("en", "comments", Message{One: "{count} comment", Other: "{count} comments"})
+	c.Set("ru", "comments", Message{One: "{count} комментарий", Few: "{count} комментария", Many: "{count} комментариев", Other: "{count} комментария"})
+	return c
+}
+
+func TestCatalog(t *testing.T) {
+	c := testCatalog()
+	for _, tc := range []struct {
+		comment string
+		locale  string
+		id      string
+		count   int64
+		plural  bool
+		args    []Arg
+		want    string
+		wantErr error
+	}{
+		{
+			comment: "text",
+			locale:  "de-DE",
+			id:      "welcome",
+			args:    []Arg{{Name: "name", Value: "Adam"}},
+			want:    "Willkommen, Adam!",
+		},
+		{
+			comment: "fallback locale",
+			locale:  "ja",
+			id:      "welcome",
+			args:    []Arg{{Name: "name", Value: "Adam"}},
+			want:    "Welcome, Adam!",
+		},
+		{
+			comment: "args are escaped",
+			locale:  "en",
+			id:      "welcome",
+			args:    []Arg{{Name: "name", Value: "<script>"}},
+			want:    "Welcome, &lt;script&gt;!",
+		},
+		{
+			comment: "plural one",
+			locale:  "en",
+			id:      "comments",
+			count:   1,
+			plural:  true,
+			want:    "1 comment",
+		},
+		{
+			comment: "plural other with grouping",
+			locale:  "en",
+			id:      "comments",
+			count:   1200,
+			plural:  true,
+			want:    "1,200 comments",
+		},
+		{
+			comment: "plural few",
+			locale:  "ru",
+			id:      "comments",
+			count:   3,
+			plural:  true,
+			want:    "3 комментария",
+		},
+		{
+			comment: "missing",
+			locale:  "en",
+			id:      "nope",
+			wantErr: ErrMissingMessage,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			var got safe.Text
+			var err error
+			if tc.plural {
+				got, err = c.Plural(tc.locale, tc.id, tc.count, tc.args...)
+			} else {
+				got, err = c.Text(tc.locale, tc.id, tc.args...)
+			}
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("translating %q to %q => %v, wanted error %v", tc.id, tc.locale, err, tc.wantErr)
+			}
+			if got.String() != tc.want {
+				t.Errorf("translating %q to %q => %q, wanted %q", tc.id, tc.locale, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBindPlural(t *testing.T) {
+	c := testCatalog()
+	var m bindings.Map
+	count := m.Declare("count", safe.Default)
+	label := m.Declare("label", safe.TextSafe)
+
+	vm := m.MustBind(count.BindInt(5))
+	vm.Locale = "ru"
+	if err := c.BindPlural(vm, label, "comments", count); err != nil {
+		t.Fatalf("BindPlural: %v", err)
+	}
+	if got, want := vm.GetString(label), "5 комментариев"; got != want {
+		t.Errorf("GetString(%v) after BindPlural => %q, wanted %q", label, got, want)
+	}
+
+	if err := c.BindPlural(m.MustBind(), label, "comments", count); !errors.Is(err, bindings.ErrUnset) {
+		t.Errorf("BindPlural without a count => %v, wanted %v", err, bindings.ErrUnset)
+	}
+}
+
+func TestCatalogNoOther(t *testing.T) {
+	var c Catalog
+	if err := c.Set("en", "comments", Message{One: "{count} comment"}); !errors.Is(err, ErrNoOther) {
+		t.Errorf("Set() of a message without Other => %v, wanted %v", err, ErrNoOther)
+	}
+
+	file := `{"locale": "en", "messages": [
+		{"id": "untranslated", "other": ""},
+		{"id": "comments", "one": "{count} comment", "other": ""}
+	]}`
+	if err := c.LoadJSON(strings.NewReader(file)); !errors.Is(err, ErrNoOther) {
+		t.Errorf("LoadJSON() of a message without Other => %v, wanted %v", err, ErrNoOther)
+	}
+	if _, ok := c.Lookup("en", "comments"); ok {
+		t.Errorf("Lookup(%q) found a message without Other", "comments")
+	}
+}
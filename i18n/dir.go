@@ -0,0 +1,30 @@
+package i18n
+
+// Languages written right to left, by base language.
+var rtlLanguages = map[string]bool{
+	"ar":  true,
+	"ckb": true,
+	"dv":  true,
+	"fa":  true,
+	"he":  true,
+	"ks":  true,
+	"ps":  true,
+	"sd":  true,
+	"ug":  true,
+	"ur":  true,
+	"yi":  true,
+}
+
+// IsRTL returns whether the locale's language is written right to left.
+func IsRTL(locale string) bool {
+	return rtlLanguages[BaseLanguage(locale)]
+}
+
+// Dir returns the value of the HTML dir attribute for the locale: "rtl" or
+// "ltr".
+func Dir(locale string) string {
+	if IsRTL(locale) {
+		return "rtl"
+	}
+	return "ltr"
+}
@@ -0,0 +1,21 @@
+package i18n
+
+import "testing"
+
+func TestDir(t *testing.T) {
+	for _, tc := range []struct {
+		locale string
+		want   string
+	}{
+		{locale: "", want: "ltr"},
+		{locale: "en-US", want: "ltr"},
+		{locale: "ar", want: "rtl"},
+		{locale: "he_IL", want: "rtl"},
+		{locale: "FA-ir", want: "rtl"},
+		{locale: "ja", want: "ltr"},
+	} {
+		if got := Dir(tc.locale); got != tc.want {
+			t.Errorf("Dir(%q) => %q, wanted %q", tc.locale, got, tc.want)
+		}
+	}
+}
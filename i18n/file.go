@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// File is the JSON format of a message catalog for a single locale. The
+// html5-extract command writes Files with the default text of every message,
+// which translators then copy and translate.
+type File struct {
+	Locale   string        `json:"locale"`
+	Messages []FileMessage `json:"messages"`
+}
+
+// FileMessage is a single message in a File.
+type FileMessage struct {
+	ID    string `json:"id"`
+	Zero  string `json:"zero,omitempty"`
+	One   string `json:"one,omitempty"`
+	Two   string `json:"two,omitempty"`
+	Few   string `json:"few,omitempty"`
+	Many  string `json:"many,omitempty"`
+	Other string `json:"other"`
+	// Positions lists the places in source code where the message is used,
+	// like "page.go:12:3". Only informative, for translators.
+	Positions []string `json:"positions,omitempty"`
+}
+
+// Message returns the translation in the FileMessage.
+func (fm *FileMessage) Message() Message {
+	return Message{Zero: fm.Zero, One: fm.One, Two: fm.Two, Few: fm.Few, Many: fm.Many, Other: fm.Other}
+}
+
+// WriteJSON writes the File to w as indented JSON.
+func WriteJSON(w io.Writer, f *File) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(f)
+}
+
+// LoadJSON reads a File from r and adds its messages to the Catalog. Messages
+// that haven't been translated (all forms are empty) are skipped. Messages with
+// plural forms, but no Other form, are rejected with ErrNoOther.
+func (c *Catalog) LoadJSON(r io.Reader) error {
+	var f File
+	if err := json.NewDecoder(r).Decode(&f); err != nil {
+		return fmt.Errorf("decoding message catalog: %w", err)
+	}
+	if f.Locale == "" {
+		return fmt.Errorf("message catalog has no locale")
+	}
+	for i := range f.Messages {
+		fm := &f.Messages[i]
+		if fm.Message() == (Message{}) {
+			continue
+		}
+		if err := c.Set(f.Locale, fm.ID, fm.Message()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package i18n
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// numberFormat describes how a locale writes numbers.
+type numberFormat struct {
+	group   string
+	decimal string
+	// Numbers with fewer integer digits than this aren't grouped. (Spanish
+	// writes 1000, but 10.000.)
+	minGrouping int
+}
+
+var (
+	commaPoint  = numberFormat{group: ",", decimal: ".", minGrouping: 4}
+	pointComma  = numberFormat{group: ".", decimal: ",", minGrouping: 4}
+	spaceComma  = numberFormat{group: "\u00a0", decimal: ",", minGrouping: 4}
+	narrowComma = numberFormat{group: "\u202f", decimal: ",", minGrouping: 4}
+)
+
+var numberFormats = map[string]numberFormat{
+	"cs":    spaceComma,
+	"da":    pointComma,
+	"de":    pointComma,
+	"en":    commaPoint,
+	"es":    {group: ".", decimal: ",", minGrouping: 5},
+	"fr":    narrowComma,
+	"id":    pointComma,
+	"it":    pointComma,
+	"ja":    commaPoint,
+	"ko":    commaPoint,
+	"nl":    pointComma,
+	"pl":    {group: "\u00a0", decimal: ",", minGrouping: 5},
+	"pt":    pointComma,
+	"pt-pt": spaceComma,
+	"ru":    spaceComma,
+	"sk":    spaceComma,
+	"sv":    spaceComma,
+	"th":    commaPoint,
+	"tr":    pointComma,
+	"uk":    spaceComma,
+	"zh":    commaPoint,
+}
+
+// Short, numeric date layouts (see time.Time.Format), after CLDR.
+var dateLayouts = map[string]string{
+	"ar":    "2/1/2006",
+	"cs":    "2. 1. 2006",
+	"da":    "2.1.2006",
+	"de":    "02.01.2006",
+	"en":    "1/2/2006",
+	"en-au": "2/1/2006",
+	"en-gb": "02/01/2006",
+	"en-in": "2/1/2006",
+	"es":    "2/1/2006",
+	"fr":    "02/01/2006",
+	"fr-ca": "2006-01-02",
+	"he":    "2.1.2006",
+	"it":    "02/01/2006",
+	"ja":    "2006/01/02",
+	"ko":    "2006. 1. 2.",
+	"nl":    "02-01-2006",
+	"pl":    "02.01.2006",
+	"pt":    "02/01/2006",
+	"ru":    "02.01.2006",
+	"sv":    "2006-01-02",
+	"tr":    "02.01.2006",
+	"uk":    "02.01.2006",
+	"zh":    "2006/1/2",
+}
+
+// ISO 8601 is used for locales without a known layout.
+const defaultDateLayout = "2006-01-02"
+
+func lookupNumberFormat(locale string) numberFormat {
+	if f, ok := numberFormats[Normalize(locale)]; ok {
+		return f
+	}
+	if f, ok := numberFormats[BaseLanguage(locale)]; ok {
+		return f
+	}
+	return commaPoint
+}
+
+// FormatInt formats the integer with the locale's digit grouping, like "1,234"
+// in English or "1.234" in German.
+func FormatInt(locale string, n int64) string {
+	return groupDigits(lookupNumberFormat(locale), strconv.FormatInt(n, 10))
+}
+
+// FormatFloat formats the number with the locale's digit grouping and decimal
+// separator. Prec controls the number of digits after the decimal separator,
+// as in strconv.FormatFloat.
+func FormatFloat(locale string, f float64, prec int) string {
+	nf := lookupNumberFormat(locale)
+	s := strconv.FormatFloat(f, 'f', prec, 64)
+	integer, fraction := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		integer, fraction = s[:i], s[i+1:]
+	}
+	integer = groupDigits(nf, integer)
+	if fraction == "" {
+		return integer
+	}
+	return integer + nf.decimal + fraction
+}
+
+// FormatDate formats the date with the locale's short, numeric layout, like
+// "1/2/2006" in US English or "02.01.2006" in German.
+func FormatDate(locale string, t time.Time) string {
+	return t.Format(DateLayout(locale))
+}
+
+// DateLayout returns the layout FormatDate uses for the locale.
+func DateLayout(locale string) string {
+	if l, ok := dateLayouts[Normalize(locale)]; ok {
+		return l
+	}
+	if l, ok := dateLayouts[BaseLanguage(locale)]; ok {
+		return l
+	}
+	return defaultDateLayout
+}
+
+// groupDigits inserts group separators into a string of decimal digits, which
+// may have a leading minus sign. Other strings, like "NaN", are unchanged.
+func groupDigits(nf numberFormat, digits string) string {
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) < nf.minGrouping || strings.Trim(digits, "0123456789") != "" {
+		return sign + digits
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	first := len(digits) % 3
+	if first == 0 {
+		first = 3
+	}
+	sb.WriteString(digits[:first])
+	for i := first; i < len(digits); i += 3 {
+		sb.WriteString(nf.group)
+		sb.WriteString(digits[i : i+3])
+	}
+	return sb.String()
+}
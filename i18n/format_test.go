@@ -0,0 +1,63 @@
+package i18n
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatInt(t *testing.T) {
+	for _, tc := range []struct {
+		locale string
+		input  int64
+		want   string
+	}{
+		{locale: "en", input: 1234567, want: "1,234,567"},
+		{locale: "en-US", input: -1234, want: "-1,234"},
+		{locale: "en", input: 999, want: "999"},
+		{locale: "de", input: 1234567, want: "1.234.567"},
+		{locale: "fr_FR", input: 12345, want: "12\u202f345"},
+		{locale: "es", input: 1234, want: "1234"},
+		{locale: "es", input: 12345, want: "12.345"},
+		{locale: "xx", input: 1000, want: "1,000"},
+	} {
+		if got := FormatInt(tc.locale, tc.input); got != tc.want {
+			t.Errorf("FormatInt(%q, %d) => %q, wanted %q", tc.locale, tc.input, got, tc.want)
+		}
+	}
+}
+
+func TestFormatFloat(t *testing.T) {
+	for _, tc := range []struct {
+		locale string
+		input  float64
+		prec   int
+		want   string
+	}{
+		{locale: "en", input: 1234.5, prec: 2, want: "1,234.50"},
+		{locale: "de", input: 1234.5, prec: -1, want: "1.234,5"},
+		{locale: "ru", input: -0.25, prec: 2, want: "-0,25"},
+		{locale: "en", input: 3, prec: 0, want: "3"},
+	} {
+		if got := FormatFloat(tc.locale, tc.input, tc.prec); got != tc.want {
+			t.Errorf("FormatFloat(%q, %v, %d) => %q, wanted %q", tc.locale, tc.input, tc.prec, got, tc.want)
+		}
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	date := time.Date(2021, time.March, 4, 0, 0, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		locale string
+		want   string
+	}{
+		{locale: "en-US", want: "3/4/2021"},
+		{locale: "en-GB", want: "04/03/2021"},
+		{locale: "de-AT", want: "04.03.2021"},
+		{locale: "ja", want: "2021/03/04"},
+		{locale: "xx", want: "2021-03-04"},
+	} {
+		if got := FormatDate(tc.locale, date); got != tc.want {
+			t.Errorf("FormatDate(%q, %v) => %q, wanted %q", tc.locale, date, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package i18n
+
+import "strings"
+
+// Plural is a CLDR plural category. Languages use different subsets of the
+// categories: English only needs One and Other, while Arabic uses all six.
+//
+// See https://cldr.unicode.org/index/cldr-spec/plural-rules
+type Plural int16
+
+const (
+	Other Plural = iota
+	Zero
+	One
+	Two
+	Few
+	Many
+)
+
+func (p Plural) String() string {
+	switch p {
+	case Other:
+		return "other"
+	case Zero:
+		return "zero"
+	case One:
+		return "one"
+	case Two:
+		return "two"
+	case Few:
+		return "few"
+	case Many:
+		return "many"
+	default:
+		panic("unknown plural category")
+	}
+}
+
+// PluralRule selects the plural category for a count.
+type PluralRule func(n int64) Plural
+
+// PluralRuleFor returns the cardinal plural rule for the locale, or else for
+// its base language. Locales without a known rule get the English rule (One
+// for 1, Other for everything else).
+func PluralRuleFor(locale string) PluralRule {
+	if r, ok := pluralRules[Normalize(locale)]; ok {
+		return r
+	}
+	if r, ok := pluralRules[BaseLanguage(locale)]; ok {
+		return r
+	}
+	return pluralOneOther
+}
+
+func pluralOneOther(n int64) Plural {
+	if abs(n) == 1 {
+		return One
+	}
+	return Other
+}
+
+// Used by French and Brazilian Portuguese, where zero is singular.
+func pluralZeroOneOther(n int64) Plural {
+	if n = abs(n); n == 0 || n == 1 {
+		return One
+	}
+	return Other
+}
+
+func pluralOther(int64) Plural {
+	return Other
+}
+
+// Used by Russian, Ukrainian and Belarusian.
+func pluralEastSlavic(n int64) Plural {
+	n = abs(n)
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return One
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return Few
+	default:
+		return Many
+	}
+}
+
+func pluralPolish(n int64) Plural {
+	n = abs(n)
+	switch {
+	case n == 1:
+		return One
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return Few
+	default:
+		return Many
+	}
+}
+
+// Used by Czech and Slovak.
+func pluralWestSlavic(n int64) Plural {
+	n = abs(n)
+	switch {
+	case n == 1:
+		return One
+	case n >= 2 && n <= 4:
+		return Few
+	default:
+		return Other
+	}
+}
+
+func pluralHebrew(n int64) Plural {
+	switch abs(n) {
+	case 1:
+		return One
+	case 2:
+		return Two
+	default:
+		return Other
+	}
+}
+
+func pluralArabic(n int64) Plural {
+	n = abs(n)
+	switch {
+	case n == 0:
+		return Zero
+	case n == 1:
+		return One
+	case n == 2:
+		return Two
+	case n%100 >= 3 && n%100 <= 10:
+		return Few
+	case n%100 >= 11:
+		return Many
+	default:
+		return Other
+	}
+}
+
+var pluralRules = map[string]PluralRule{
+	"ar": pluralArabic,
+	"be": pluralEastSlavic,
+	"cs": pluralWestSlavic,
+	"da": pluralOneOther,
+	"de": pluralOneOther,
+	"en": pluralOneOther,
+	"es": pluralOneOther,
+	"fr": pluralZeroOneOther,
+	"he": pluralHebrew,
+	"id": pluralOther,
+	"it": pluralOneOther,
+	"ja": pluralOther,
+	"ko": pluralOther,
+	"nl": pluralOneOther,
+	"pl": pluralPolish,
+	"pt": pluralZeroOneOther,
+	"ru": pluralEastSlavic,
+	"sk": pluralWestSlavic,
+	"sv": pluralOneOther,
+	"th": pluralOther,
+	"tr": pluralOneOther,
+	"uk": pluralEastSlavic,
+	"vi": pluralOther,
+	"zh": pluralOther,
+
+	// Regional rules, which take precedence over the base language's.
+	// Unlike Brazil, Portugal only uses the singular for 1.
+	"pt-pt": pluralOneOther,
+}
+
+func abs(n int64) int64 {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Normalize converts a locale name like "pt_BR" to the canonical form used by
+// this package, "pt-br".
+func Normalize(locale string) string {
+	return strings.ToLower(strings.Replace(locale, "_", "-", -1))
+}
+
+// BaseLanguage returns the normalized language part of a locale name, like
+// "pt" for "pt-BR".
+func BaseLanguage(locale string) string {
+	locale = Normalize(locale)
+	if i := strings.IndexByte(locale, '-'); i >= 0 {
+		return locale[:i]
+	}
+	return locale
+}
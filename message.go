@@ -0,0 +1,218 @@
+package html5
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/i18n"
+	"github.com/the80srobot/html5/safe"
+)
+
+type constString string
+
+// Translatable is static text that can be translated with an i18n.Catalog. It
+// can be used as the Value of text nodes and attributes. Create Translatables
+// with T.
+//
+// If CompileOptions.Catalog is set, then the Template renders the translation
+// for the locale of the root ValueMap (see bindings.ValueMap.Locale). Otherwise,
+// or if there is no translation, the default text is used.
+type Translatable struct {
+	ID      string
+	Default string
+}
+
+// T returns a Translatable with the message ID and default text. To guarantee
+// safety, T only accepts string literals for the default text. Translations
+// aren't trusted, and are escaped.
+//
+// The html5-extract command finds calls to T and writes message catalogs for
+// translators, so the arguments should be string literals.
+func T(id string, text constString) Translatable {
+	return Translatable{ID: id, Default: string(text)}
+}
+
+// Check returns whether the text is suitable for the required trust level.
+// Translations are escaped, and so are fit for use in text and attributes.
+func (t Translatable) Check(required safe.TrustLevel) bool {
+	switch required {
+	case safe.TextSafe, safe.AttributeSafe, safe.Untrusted:
+		return true
+	default:
+		return false
+	}
+}
+
+func (t Translatable) String() string {
+	return fmt.Sprintf("T(%q, %q)", t.ID, t.Default)
+}
+
+// appendMessage appends the Translatable to the template, as formatted by
+// the format function. Without a Catalog, the default text is formatted once,
+// as static text. Otherwise, every translation in the Catalog is formatted
+// ahead of time, and the right one is picked at render time.
+func (tc *templateCompiler) appendMessage(t Translatable, format func(w io.Writer, s string) error) error {
+	catalog := tc.catalog
+	if catalog == nil {
+		return format(tc, t.Default)
+	}
+
+	var b bytes.Buffer
+	mc := messageChunk{id: t.ID, texts: map[string]string{}}
+	for _, locale := range catalog.Locales() {
+		m, ok := catalog.Lookup(locale, t.ID)
+		if !ok {
+			continue
+		}
+		b.Reset()
+		if err := format(&b, safe.EscapeText(m.Other).String()); err != nil {
+			return err
+		}
+		mc.texts[locale] = b.String()
+	}
+
+	b.Reset()
+	if m, ok := catalog.Lookup(catalog.Fallback, t.ID); ok {
+		if err := format(&b, safe.EscapeText(m.Other).String()); err != nil {
+			return err
+		}
+	} else if err := format(&b, t.Default); err != nil {
+		return err
+	}
+	mc.fallback = b.String()
+
+	tc.appendChunk(mc)
+	return nil
+}
+
+// messageChunk holds the preformatted translations of a Translatable.
+type messageChunk struct {
+	id string
+	// Translations by normalized locale name.
+	texts    map[string]string
+	fallback string
+}
+
+func (mc messageChunk) text(locale string) string {
+	if s, ok := mc.texts[i18n.Normalize(locale)]; ok {
+		return s
+	}
+	if s, ok := mc.texts[i18n.BaseLanguage(locale)]; ok {
+		return s
+	}
+	return mc.fallback
+}
+
+func (mc messageChunk) build(w io.Writer, _ *bindings.ValueMap, rc renderContext) error {
+	return writeString(w, mc.text(rc.locale))
+}
+
+func (mc messageChunk) size(_ *bindings.ValueMap, rc renderContext) int {
+	return len(mc.text(rc.locale))
+}
+
+func (mc messageChunk) fingerprint(h *fingerprinter, _ *bindings.ValueMap, rc renderContext) {
+	h.writeString(mc.text(rc.locale))
+}
+
+func (mc messageChunk) String() string {
+	return fmt.Sprintf("message{%q, %d translations}", mc.id, len(mc.texts))
+}
+
+// localizedChunk is a chunk whose output only depends on the locale. In
+// locale variants, it's replaced by static text.
+type localizedChunk interface {
+	chunk
+	text(locale string) string
+}
+
+// compileVariants precompiles the chunks for each of opts.Locales, merging the
+// translations into the surrounding static chunks.
+func (t *Template) compileVariants(opts *CompileOptions) error {
+	if len(opts.Locales) == 0 {
+		return nil
+	}
+	if opts.Catalog == nil {
+		return errors.New("CompileOptions.Locales requires a Catalog")
+	}
+
+	translated := false
+	for _, c := range t.chunks {
+		if _, ok := c.(localizedChunk); ok {
+			translated = true
+			break
+		}
+	}
+	if !translated {
+		return nil
+	}
+
+	t.variants = make(map[string][]chunk, len(opts.Locales))
+	for _, locale := range opts.Locales {
+		t.variants[i18n.Normalize(locale)] = localizeChunks(t.chunks, locale, opts.SeparateStaticChunks)
+	}
+	return nil
+}
+
+// localizeChunks replaces localizedChunks with their text for the locale.
+// Unless separate is set, adjacent static chunks are merged.
+func localizeChunks(chunks []chunk, locale string, separate bool) []chunk {
+	var (
+		localized []chunk
+		pending   []byte
+	)
+	flush := func() {
+		if len(pending) != 0 {
+			localized = append(localized, staticChunk{pending})
+			pending = nil
+		}
+	}
+	for _, c := range chunks {
+		var data []byte
+		switch c := c.(type) {
+		case staticChunk:
+			data = c.data
+		case localizedChunk:
+			data = []byte(c.text(locale))
+		default:
+			flush()
+			localized = append(localized, c)
+			continue
+		}
+		if separate {
+			localized = append(localized, staticChunk{data})
+		} else {
+			pending = append(pending, data...)
+		}
+	}
+	flush()
+	return localized
+}
+
+// variant returns the chunks to render for the locale.
+func (t *Template) variant(locale string) []chunk {
+	if t.variants == nil {
+		return t.chunks
+	}
+	// Only exact matches: the base language's variant could be missing a
+	// regional translation that the messageChunks would find.
+	if chunks, ok := t.variants[i18n.Normalize(locale)]; ok {
+		return chunks
+	}
+	return t.chunks
+}
+
+// Locales returns the locales the Template has precompiled variants for, in
+// order. (See CompileOptions.Locales.)
+func (t *Template) Locales() []string {
+	locales := make([]string, 0, len(t.variants))
+	for l := range t.variants {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
@@ -0,0 +1,187 @@
+package html5
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/i18n"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestTranslatable(t *testing.T) {
+	catalog := &i18n.Catalog{Fallback: "en"}
+	catalog.SetText("en", "welcome", "Welcome!")
+	catalog.SetText("de", "welcome", "Willkommen!")
+	catalog.SetText("pt-BR", "welcome", "Bem-vindo!")
+	catalog.SetText("fr", "welcome", "<b>Bienvenue</b>")
+	catalog.SetText("de", "search", "Suchen")
+
+	input := Element("p",
+		Attribute("title", T("search", "Search")),
+		Text(T("welcome", "Hello!")))
+
+	for _, tc := range []struct {
+		comment string
+		opts    *CompileOptions
+		locale  string
+		output  string
+	}{
+		{
+			comment: "no catalog",
+			opts:    &CompileOptions{Compact: true},
+			locale:  "de",
+			output:  `<p title="Search">Hello!</p>`,
+		},
+		{
+			comment: "no locale",
+			opts:    &CompileOptions{Compact: true, Catalog: catalog},
+			output:  `<p title="Search">Welcome!</p>`,
+		},
+		{
+			comment: "translated",
+			opts:    &CompileOptions{Compact: true, Catalog: catalog},
+			locale:  "de",
+			output:  `<p title="Suchen">Willkommen!</p>`,
+		},
+		{
+			comment: "regional locale",
+			opts:    &CompileOptions{Compact: true, Catalog: catalog},
+			locale:  "pt_BR",
+			output:  `<p title="Search">Bem-vindo!</p>`,
+		},
+		{
+			comment: "base language",
+			opts:    &CompileOptions{Compact: true, Catalog: catalog},
+			locale:  "de-AT",
+			output:  `<p title="Suchen">Willkommen!</p>`,
+		},
+		{
+			comment: "unknown locale",
+			opts:    &CompileOptions{Compact: true, Catalog: catalog},
+			locale:  "ja",
+			output:  `<p title="Search">Welcome!</p>`,
+		},
+		{
+			comment: "translations are escaped",
+			opts:    &CompileOptions{Compact: true, Catalog: catalog},
+			locale:  "fr",
+			output:  `<p title="Search">&lt;b&gt;Bienvenue&lt;/b&gt;</p>`,
+		},
+		{
+			comment: "wrapped",
+			opts:    &CompileOptions{Catalog: catalog, Indent: "  ", TextWidth: 1},
+			locale:  "pt-br",
+			output:  "<p title=\"Search\">\n  Bem-vindo!\n</p>",
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			var m bindings.Map
+			tmpl := MustCompile(input, &m, tc.opts)
+			vm := m.MustBind()
+			vm.Locale = tc.locale
+			var sb strings.Builder
+			if err := tmpl.GenerateHTML(&sb, vm); err != nil {
+				t.Fatalf("GenerateHTML: %v", err)
+			}
+			if diff := cmp.Diff(tc.output, sb.String()); diff != "" {
+				t.Errorf("GenerateHTML(%v, %q)\n => (-)wanted vs (+)got:\n%s", input, tc.locale, diff)
+			}
+		})
+	}
+}
+
+func TestTranslatableTrust(t *testing.T) {
+	var m bindings.Map
+	if _, err := Compile(Element("a", Attribute("href", T("link", "/"))), &m, &CompileOptions{}); err == nil {
+		t.Error("Compile(T in href) => nil error, wanted untrusted string error")
+	}
+}
+
+func TestLocaleVariants(t *testing.T) {
+	catalog := &i18n.Catalog{Fallback: "en"}
+	catalog.SetText("en", "welcome", "Welcome!")
+	catalog.SetText("de", "welcome", "Willkommen!")
+	catalog.SetText("de", "tag", "Schlagwort:")
+	catalog.SetText("fr", "welcome", "Bienvenue !")
+	catalog.SetText("fr-CA", "welcome", "Bienvenue!")
+
+	input := Element("div",
+		Element("h1", Text(T("welcome", "Hello!"))),
+		&SubsectionNode{
+			Name:      "tags",
+			Prototype: Element("p", Text(T("tag", "Tag:"), bindings.Declare("tag", safe.Default))),
+		})
+	opts := &CompileOptions{Compact: true, Catalog: catalog, Locales: []string{"de", "fr"}}
+	var m bindings.Map
+	tmpl := MustCompile(input, &m, opts)
+
+	if diff := cmp.Diff([]string{"de", "fr"}, tmpl.Locales()); diff != "" {
+		t.Errorf("Locales() => (-)wanted vs (+)got:\n%s", diff)
+	}
+	// The variant is static up to the subsection.
+	if got := len(tmpl.variant("de")); got != 3 {
+		t.Errorf("len(variant(de)) => %d, wanted 3 (static, subsection, static)", got)
+	}
+
+	tags := m.Nest("tags")
+	tag, _ := tags.Lookup("tag")
+	for _, tc := range []struct {
+		comment string
+		locale  string
+		opts    *RenderOptions
+		output  string
+	}{
+		{
+			comment: "variant",
+			locale:  "de",
+			output:  `<div><h1>Willkommen!</h1><p>Schlagwort:a</p><p>Schlagwort:b</p></div>`,
+		},
+		{
+			comment: "render option overrides ValueMap",
+			locale:  "de",
+			opts:    &RenderOptions{Locale: "fr"},
+			output:  `<div><h1>Bienvenue !</h1><p>Tag:a</p><p>Tag:b</p></div>`,
+		},
+		{
+			comment: "regional locale without variant",
+			locale:  "fr-CA",
+			output:  `<div><h1>Bienvenue!</h1><p>Tag:a</p><p>Tag:b</p></div>`,
+		},
+		{
+			comment: "locale without variant or translation",
+			locale:  "ja",
+			output:  `<div><h1>Welcome!</h1><p>Tag:a</p><p>Tag:b</p></div>`,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			vm := m.MustBind(tags.BindSeries(
+				tags.MustBind(tag.BindConst("a")),
+				tags.MustBind(tag.BindConst("b"))))
+			vm.Locale = tc.locale
+			ro := tc.opts
+			if ro == nil {
+				ro = &RenderOptions{}
+			}
+			var sb strings.Builder
+			if err := tmpl.Render(&sb, vm, ro); err != nil {
+				t.Fatalf("Render: %v", err)
+			}
+			if diff := cmp.Diff(tc.output, sb.String()); diff != "" {
+				t.Errorf("Render(%q, %+v)\n => (-)wanted vs (+)got:\n%s", tc.locale, ro, diff)
+			}
+		})
+	}
+
+	if _, err := Compile(input, &bindings.Map{}, &CompileOptions{Locales: []string{"de"}}); err == nil {
+		t.Error("Compile with Locales and no Catalog => nil error")
+	}
+}
+
+func testCatalog() *i18n.Catalog {
+	catalog := &i18n.Catalog{Fallback: "en"}
+	catalog.SetText("en", "hello", "Hello")
+	catalog.SetText("he", "hello", "שלום")
+	return catalog
+}
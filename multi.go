@@ -29,6 +29,10 @@ func (m *MultiNode) Apply(n Node) error {
 	return nil
 }
 
+func (m *MultiNode) clone() Node {
+	return &MultiNode{Contents: cloneNodes(m.Contents)}
+}
+
 func (m *MultiNode) compile(db *templateCompiler, depth int, opts *CompileOptions) error {
 	for _, c := range m.Contents {
 		if err := c.compile(db, depth, opts); err != nil {
@@ -1,6 +1,10 @@
 package html5
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/the80srobot/html5/i18n"
+)
 
 type IndentStyle int16
 
@@ -33,8 +37,60 @@ type CompileOptions struct {
 	SeparateStaticChunks bool
 	TextWidth            int
 	RootDepth            int
+	// Catalog, if set, supplies translations for Translatable text. (See T.)
+	Catalog *i18n.Catalog
+	// Locales, if set, are compiled ahead of time: the Template gets a variant
+	// for each locale, with translations merged into the static text. Other
+	// locales still render correctly, but look translations up per chunk.
+	// Requires a Catalog.
+	Locales []string
+	// AutoDir adds dir attributes where they're missing: on html and body,
+	// from the locale being rendered (see LocaleDir), and dir="auto" on text
+	// inputs and textareas.
+	AutoDir bool
+	// FlushAfterHead adds a FlushPoint after every </head>.
+	FlushAfterHead bool
+	// CSRFField, if set, adds a hidden input with this name to every
+	// <form method="post">, including forms in subsections and deferred
+	// content. Its value is RenderOptions.CSRFToken. (See httpx.CSRF.)
+	CSRFField string
+
+	// inFragment is set while compiling a fragment's standalone Template.
+	inFragment bool
 }
 
+// RenderOptions control how a Template generates HTML.
+type RenderOptions struct {
+	// If true, then rendering fails with bindings.ErrUnset when the Template
+	// uses a Var or nested Map that was never set (and has no default).
+	Strict bool
+	// If true, then the ValueMap is checked with ValueMap.Validate before
+	// anything is written, and rendering fails with the aggregated error if any
+	// values are missing.
+	Validate bool
+	// Locale, if set, selects the translation instead of the ValueMap's
+	// Locale.
+	Locale string
+	// Flush, if set, is called at every FlushPoint instead of flushing the
+	// writer.
+	Flush func() error
+	// Nonce, if set, is added to the inline scripts that swap in deferred
+	// content, to satisfy a Content-Security-Policy. It must be unique for
+	// every response. (See DeferredNode.)
+	Nonce string
+	// Presize, if true, makes Render grow a *bytes.Buffer to EstimateSize
+	// before rendering into it. This walks every ValueStream and evaluates
+	// every Condition twice, so it's only worth it for ValueSeries and cheap
+	// Conditions. Otherwise, the buffer is only grown by StaticSize.
+	Presize bool
+	// CSRFToken is the value of the hidden inputs added by
+	// CompileOptions.CSRFField. It must be set for every request. (See
+	// httpx.CSRF.)
+	CSRFToken string
+}
+
+var defaultRenderOptions RenderOptions
+
 func (opts *CompileOptions) String() string {
 	return fmt.Sprintf("{Indent: %q, Compact: %v, SeparateStaticChunks: %v}", opts.Indent, opts.Compact, opts.SeparateStaticChunks)
 }
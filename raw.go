@@ -25,6 +25,11 @@ func (r *RawNode) Apply(n Node) error {
 	return nil
 }
 
+func (r *RawNode) clone() Node {
+	c := *r
+	return &c
+}
+
 func (r *RawNode) compile(tc *templateCompiler, _ int, _ *CompileOptions) error {
 	switch v := r.HTML.(type) {
 	case safe.String:
@@ -35,8 +40,8 @@ func (r *RawNode) compile(tc *templateCompiler, _ int, _ *CompileOptions) error
 		_, err = tc.WriteString(s)
 		return err
 	case bindings.Var:
-		tc.appendVar(v, safe.HTMLSafe)
-		return nil
+		_, err := tc.appendVar(v, safe.HTMLSafe)
+		return err
 	default:
 		return fmt.Errorf("value must be safe.String or *bindings.Var, %v (%v) is neither", v, reflect.TypeOf(v))
 	}
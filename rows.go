@@ -0,0 +1,116 @@
+package html5
+
+import (
+	"sync"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// Row metadata Vars. Inside the Prototype of a SubsectionNode, they hold the
+// position of the row being rendered, so they can be used in text, attributes
+// and SwitchNode conditions, without the caller binding them on every row.
+// They're trusted, because their values are only ever numbers and keywords.
+//
+// Their names are reserved in the nested Map: the subsection overwrites any
+// values the caller binds to them. Outside of rendering, they default to "".
+//
+// Conditions don't declare the Vars they read, so a Prototype that only uses
+// these Vars in conditions must list them in SwitchNode.Vars.
+var (
+	// RowIndexVar is the index of the row, from 0.
+	RowIndexVar = bindings.Declare("row_index", safe.FullyTrusted)
+	// RowNumberVar is the number of the row, from 1.
+	RowNumberVar = bindings.Declare("row_number", safe.FullyTrusted)
+	// RowParityVar is "odd" or "even", by RowNumberVar, so it can be used as a
+	// class for striping.
+	RowParityVar = bindings.Declare("row_parity", safe.FullyTrusted)
+	// RowEvenVar is true for even rows, by RowNumberVar. Use it with IsTrue.
+	RowEvenVar = bindings.Declare("row_even", safe.FullyTrusted)
+	// RowFirstVar is true for the first row. Use it with IsTrue.
+	RowFirstVar = bindings.Declare("row_first", safe.FullyTrusted)
+	// RowLastVar is true for the last row. Use it with IsTrue.
+	RowLastVar = bindings.Declare("row_last", safe.FullyTrusted)
+	// RowCountVar is the number of rows. It's only known if the stream is a
+	// bindings.ValueSeries, and otherwise it stays unset.
+	RowCountVar = bindings.Declare("row_count", safe.FullyTrusted)
+)
+
+// rowMeta holds the row metadata Vars declared in a subsection's Map. Vars the
+// subsection doesn't use are ZeroVar.
+type rowMeta struct {
+	index, number, parity, even, first, last, count bindings.Var
+}
+
+// declaredRowMeta returns the row metadata Vars declared in the Map, or nil if
+// there are none. Unless the Map is frozen, it also gives them defaults, so
+// that ValueMap.Validate doesn't expect the caller to bind them.
+func declaredRowMeta(m *bindings.Map) (*rowMeta, error) {
+	var rm rowMeta
+	used := false
+	for _, f := range []struct {
+		v   bindings.Var
+		dst *bindings.Var
+	}{
+		{RowIndexVar, &rm.index},
+		{RowNumberVar, &rm.number},
+		{RowParityVar, &rm.parity},
+		{RowEvenVar, &rm.even},
+		{RowFirstVar, &rm.first},
+		{RowLastVar, &rm.last},
+		{RowCountVar, &rm.count},
+	} {
+		v, ok := m.Lookup(f.v.Name())
+		if !ok {
+			continue
+		}
+		if !m.Frozen() {
+			if err := m.SetDefault(v, safe.Const("")); err != nil {
+				return nil, err
+			}
+		}
+		*f.dst = v
+		used = true
+	}
+	if !used {
+		return nil, nil
+	}
+	return &rm, nil
+}
+
+// set makes row a copy of values, with the metadata of the i-th row. Count is
+// -1 if the number of rows isn't known.
+func (rm *rowMeta) set(row, values *bindings.ValueMap, i, count int, last bool) error {
+	row.CopyFrom(values)
+	even := i%2 == 1
+	parity := rm.parity.BindConst("odd")
+	if even {
+		parity = rm.parity.BindConst("even")
+	}
+	for _, rv := range [...]struct {
+		v     bindings.Var
+		value bindings.Value
+	}{
+		{rm.index, rm.index.BindInt(int64(i))},
+		{rm.number, rm.number.BindInt(int64(i + 1))},
+		{rm.parity, parity},
+		{rm.even, rm.even.BindBool(even)},
+		{rm.first, rm.first.BindBool(i == 0)},
+		{rm.last, rm.last.BindBool(last)},
+		{rm.count, rm.count.BindInt(int64(count))},
+	} {
+		if rv.v == bindings.ZeroVar || (rv.v == rm.count && count < 0) {
+			continue
+		}
+		if err := row.Set(rv.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowPool holds the ValueMaps that subsections with row metadata render their
+// rows with, so that the caller's ValueMaps aren't modified.
+var rowPool = sync.Pool{
+	New: func() interface{} { return new(bindings.ValueMap) },
+}
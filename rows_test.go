@@ -0,0 +1,131 @@
+package html5
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// iteratorStream is a ValueStream that isn't a ValueSeries, so its length is
+// unknown.
+type iteratorStream bindings.ValueSeries
+
+func (s iteratorStream) Stream() bindings.ValueIterator {
+	return bindings.ValueSeries(s).Stream()
+}
+
+func TestRowMetadata(t *testing.T) {
+	input := Element("ol", &SubsectionNode{
+		Name: "items",
+		Prototype: Element("li",
+			Attribute("class", RowParityVar),
+			Attribute("data-index", RowIndexVar),
+			Text(RowNumberVar, safe.Const("/"), RowCountVar, safe.Const(" "), bindings.Declare("item", safe.Default)),
+			&SwitchNode{
+				Cases: []Case{
+					{Condition: IsTrue(RowFirstVar), Output: Text(safe.Const(" (first)"))},
+					{Condition: IsTrue(RowLastVar), Output: Text(safe.Const(" (last)"))},
+				},
+				Vars: []bindings.Var{RowFirstVar, RowLastVar},
+			},
+		),
+	})
+
+	var m bindings.Map
+	tmpl := MustCompile(input, &m, &Compact)
+	items := m.Nest("items")
+	item, _ := items.Lookup("item")
+	rows := []*bindings.ValueMap{
+		items.MustBind(item.BindConst("a")),
+		items.MustBind(item.BindConst("b")),
+		items.MustBind(item.BindConst("c")),
+	}
+
+	for _, tc := range []struct {
+		comment string
+		stream  bindings.ValueStream
+		want    string
+	}{
+		{
+			comment: "series",
+			stream:  bindings.ValueSeries(rows),
+			want: `<ol>` +
+				`<li class="odd" data-index="0">1/3 a (first)</li>` +
+				`<li class="even" data-index="1">2/3 b</li>` +
+				`<li class="odd" data-index="2">3/3 c (last)</li>` +
+				`</ol>`,
+		},
+		{
+			comment: "iterator",
+			stream:  iteratorStream(rows),
+			want: `<ol>` +
+				`<li class="odd" data-index="0">1/ a (first)</li>` +
+				`<li class="even" data-index="1">2/ b</li>` +
+				`<li class="odd" data-index="2">3/ c (last)</li>` +
+				`</ol>`,
+		},
+		{
+			comment: "one row",
+			stream:  bindings.ValueSeries(rows[:1]),
+			want:    `<ol><li class="odd" data-index="0">1/1 a (first)</li></ol>`,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			vm := m.MustBind(items.BindStream(tc.stream))
+			if err := vm.Validate(); err != nil {
+				t.Errorf("Validate() => %v, wanted no error for unbound row metadata", err)
+			}
+			var sb strings.Builder
+			if err := tmpl.GenerateHTML(&sb, vm); err != nil {
+				t.Fatalf("GenerateHTML: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, sb.String()); diff != "" {
+				t.Errorf("GenerateHTML(%v) => (-)wanted vs (+)got:\n%s", input, diff)
+			}
+			if got, want := tmpl.EstimateSize(vm), sb.Len(); got != want {
+				t.Errorf("EstimateSize(%v) => %d, wanted %d", vm, got, want)
+			}
+		})
+	}
+
+	// The rows are rendered from copies, so the caller's ValueMaps don't get
+	// the metadata.
+	first, _ := items.Lookup(RowFirstVar.Name())
+	for i, row := range rows {
+		if _, ok := row.GetBool(first); ok {
+			t.Errorf("row %d has %v set after rendering", i, first)
+		}
+	}
+}
+
+func TestRowMetadataUnused(t *testing.T) {
+	var m bindings.Map
+	MustCompile(&SubsectionNode{Name: "items", Prototype: Text(bindings.Declare("item", safe.Default))}, &m, &Compact)
+	if _, ok := m.Nest("items").Lookup(RowIndexVar.Name()); ok {
+		t.Errorf("Compile declared %v in a subsection that doesn't use it", RowIndexVar)
+	}
+}
+
+func TestRowMetadataFingerprint(t *testing.T) {
+	// The title is only used on the first row, so the fingerprint must
+	// evaluate the condition with the metadata set.
+	var m bindings.Map
+	tmpl := MustCompile(Element("ul", &SubsectionNode{
+		Name: "items",
+		Prototype: &SwitchNode{
+			Cases: []Case{{Condition: IsTrue(RowFirstVar), Output: Text(bindings.Declare("title", safe.Default))}},
+			Vars:  []bindings.Var{RowFirstVar},
+		},
+	}), &m, &Compact)
+	items := m.Nest("items")
+	title, _ := items.Lookup("title")
+	fingerprint := func(s string) string {
+		return tmpl.Fingerprint(m.MustBind(items.BindSeries(items.MustBind(title.Bind(safe.EscapeText(s))))))
+	}
+	if a, b := fingerprint("A"), fingerprint("B"); a == b {
+		t.Errorf("Fingerprint() => %s for different titles on the first row, wanted different fingerprints", a)
+	}
+}
@@ -34,6 +34,8 @@ func escapeURL(s string) (string, error) {
 	}
 }
 
+// escapeAttribute escapes the string for a quoted attribute value. It doesn't
+// make the value safe for attributes that hold URLs, scripts or styles.
 func escapeAttribute(s string) (string, error) {
-	return "", errors.New("attributes must currently be fully trusted")
+	return html.EscapeString(s), nil
 }
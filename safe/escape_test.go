@@ -48,3 +48,33 @@ func TestEscapeURL(t *testing.T) {
 		})
 	}
 }
+
+func TestEscapeAttribute(t *testing.T) {
+	for _, tc := range []struct {
+		comment string
+		input   string
+		want    string
+	}{
+		{
+			comment: "plain",
+			input:   "hello world",
+			want:    "hello world",
+		},
+		{
+			comment: "injection",
+			input:   `" onclick="evil()`,
+			want:    "&#34; onclick=&#34;evil()",
+		},
+		{
+			comment: "markup",
+			input:   "<b>&</b>",
+			want:    "&lt;b&gt;&amp;&lt;/b&gt;",
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			if got := EscapeAttribute(tc.input).String(); got != tc.want {
+				t.Errorf("EscapeAttribute(%v) => %v, wanted %v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
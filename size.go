@@ -0,0 +1,71 @@
+package html5
+
+import "github.com/the80srobot/html5/bindings"
+
+// StaticSize returns the number of bytes of static HTML in the Template,
+// excluding subsections, switch cases and embedded Templates. It's a lower
+// bound of the size of the output, and the exact size if the Template has no
+// Vars.
+func (t *Template) StaticSize() int {
+	n := 0
+	for _, c := range t.chunks {
+		if sc, ok := c.(staticChunk); ok {
+			n += len(sc.data)
+		}
+	}
+	return n
+}
+
+// EstimateSize returns the number of bytes GenerateHTML would write for the
+// ValueMap. The estimate is exact, unless a ValueStream yields different rows
+// on different iterations, or a Condition changes its mind. Deferred content is
+// included, so EstimateSize waits for it to be ready.
+//
+// EstimateSize walks the same values as GenerateHTML, but doesn't copy them, so
+// it's a cheap way to size buffers or set the Content-Length header.
+func (t *Template) EstimateSize(vm *bindings.ValueMap) int {
+	return t.size(vm, t.newRenderContext(vm, &defaultRenderOptions))
+}
+
+func (t *Template) size(vm *bindings.ValueMap, rc renderContext) int {
+	n := 0
+	for _, c := range t.variant(rc.locale) {
+		n += c.size(vm, rc)
+	}
+	return n
+}
+
+// streamSize returns the total size of the Template rendered for each row in
+// the stream.
+func streamSize(stream bindings.ValueStream, t *Template, rc renderContext) int {
+	if stream == nil {
+		return 0
+	}
+	n := 0
+	if series, ok := stream.(bindings.ValueSeries); ok {
+		for _, values := range series {
+			n += t.size(values, rc)
+		}
+		return n
+	}
+	next := stream.Stream()
+	for values := next(); values != nil; values = next() {
+		n += t.size(values, rc)
+	}
+	return n
+}
+
+// countingWriter counts the bytes written to it, and discards them.
+type countingWriter struct {
+	n int
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.n += len(p)
+	return len(p), nil
+}
+
+func (cw *countingWriter) WriteString(s string) (int, error) {
+	cw.n += len(s)
+	return len(s), nil
+}
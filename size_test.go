@@ -0,0 +1,121 @@
+package html5
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestEstimateSize(t *testing.T) {
+	var widgetBindings bindings.Map
+	widget := MustCompile(Element("span", Text(bindings.Declare("user", safe.Default))), &widgetBindings, &Compact)
+	user, _ := widgetBindings.Lookup("user")
+
+	title := bindings.Declare("title", safe.Default)
+	body := bindings.Declare("body", safe.Default)
+	input := Element("html",
+		Attribute("dir", LocaleDir()),
+		Element("h1", Text(title)),
+		Element("p", Text(body)),
+		Element("p", Text(T("welcome", "Welcome!"))),
+		&SwitchNode{
+			Cases:   []Case{{Condition: IsSet(title), Output: Text(safe.Const("titled"))}},
+			Default: Text(safe.Const("untitled")),
+		},
+		&SubsectionNode{Name: "tags", Prototype: Element("li", Text(bindings.Declare("tag", safe.Default)))},
+		&SubsectionNode{
+			Name:      "tags",
+			Prototype: Text(bindings.Declare("tag", safe.Default)),
+			Empty:     Element("em", Text(safe.Const("none"))),
+			Separator: Text(safe.Const(", ")),
+			Before:    Element("b", Text(title)),
+			After:     Text(safe.Const(".")),
+		},
+		&TemplateNode{Template: widget, Vars: map[string]string{"user": "author"}},
+		&TemplateNode{Template: widget, Name: "likes"})
+
+	for _, opts := range []*CompileOptions{&Compact, &Tidy, {Indent: "  ", TextWidth: 10}, {Compact: true, Catalog: testCatalog()}} {
+		var m bindings.Map
+		tmpl := MustCompile(input, &m, opts)
+		tags := m.Nest("tags")
+		tag, _ := tags.Lookup("tag")
+		likes := m.Nest("likes")
+		titleVar, _ := m.Lookup("title")
+		bodyVar, _ := m.Lookup("body")
+		authorVar, _ := m.Lookup("author")
+
+		for _, vm := range []*bindings.ValueMap{
+			m.MustBind(),
+			m.MustBind(
+				titleVar.BindConst("Hello"),
+				bodyVar.Bind(safe.EscapeText("A   long body,\n with <odd> spacing and quite a few words.")),
+				authorVar.BindConst("Adam"),
+				tags.BindSeries(tags.MustBind(tag.BindConst("a")), tags.MustBind(tag.BindConst("bb"))),
+				likes.BindSeries(widgetBindings.MustBind(user.BindConst("John")), widgetBindings.MustBind(user.BindConst("Jane")))),
+		} {
+			for _, locale := range []string{"", "he"} {
+				vm.Locale = locale
+				var b bytes.Buffer
+				if err := tmpl.GenerateHTML(&b, vm); err != nil {
+					t.Fatalf("GenerateHTML: %v", err)
+				}
+				if got, want := tmpl.EstimateSize(vm), b.Len(); got != want {
+					t.Errorf("EstimateSize(%v) with options %v and locale %q => %d, wanted %d (output %q)", vm, opts, locale, got, want, b.String())
+				}
+			}
+		}
+	}
+}
+
+func TestStaticSize(t *testing.T) {
+	var m bindings.Map
+	tmpl := MustCompile(Element("p", Text(safe.Const("Hello, "), bindings.Declare("name", safe.Default))), &m, &Compact)
+	if got, want := tmpl.StaticSize(), len("<p>Hello, </p>"); got != want {
+		t.Errorf("StaticSize() => %d, wanted %d", got, want)
+	}
+}
+
+// countingStream counts how many times it's iterated.
+type countingStream struct {
+	rows  bindings.ValueSeries
+	count int
+}
+
+func (s *countingStream) Stream() bindings.ValueIterator {
+	s.count++
+	return s.rows.Stream()
+}
+
+func TestRenderPresize(t *testing.T) {
+	var m bindings.Map
+	tmpl := MustCompile(Element("ul", &SubsectionNode{
+		Name:      "tags",
+		Prototype: Element("li", Text(bindings.Declare("tag", safe.Default))),
+	}), &m, &Compact)
+	tags := m.Nest("tags")
+	tag, _ := tags.Lookup("tag")
+
+	for _, tc := range []struct {
+		presize    bool
+		wantCount  int
+		wantMinCap int
+	}{
+		{presize: false, wantCount: 1, wantMinCap: tmpl.StaticSize()},
+		{presize: true, wantCount: 2, wantMinCap: len("<ul><li>a</li><li>bb</li></ul>")},
+	} {
+		stream := &countingStream{rows: bindings.ValueSeries{tags.MustBind(tag.BindConst("a")), tags.MustBind(tag.BindConst("bb"))}}
+		vm := m.MustBind(tags.BindStream(stream))
+		var b bytes.Buffer
+		if err := tmpl.Render(&b, vm, &RenderOptions{Presize: tc.presize}); err != nil {
+			t.Fatalf("Render: %v", err)
+		}
+		if stream.count != tc.wantCount {
+			t.Errorf("Render with Presize %v => iterated the stream %d times, wanted %d", tc.presize, stream.count, tc.wantCount)
+		}
+		if b.Cap() < tc.wantMinCap {
+			t.Errorf("Render with Presize %v => buffer capacity %d, wanted at least %d", tc.presize, b.Cap(), tc.wantMinCap)
+		}
+	}
+}
@@ -12,9 +12,25 @@ import (
 // comments under an article might each be a subsection.)
 //
 // Subsections can contain other subsections.
+//
+// The Prototype can use RowIndexVar and the other row metadata Vars, which the
+// subsection sets on every row.
+//
+// The optional Empty, Separator, Before and After nodes are compiled like
+// SwitchNode cases: they're not repeated, so they use the bindings of the
+// surrounding page, not the nested Map.
 type SubsectionNode struct {
 	Prototype Node
 	Name      string
+	// Empty is rendered instead of the rows if there are none, or the stream
+	// is unset.
+	Empty Node
+	// Separator is rendered between rows.
+	Separator Node
+	// Before and After are rendered before the first row and after the last
+	// one, only if there are any rows.
+	Before Node
+	After  Node
 }
 
 func (ns *SubsectionNode) Apply(n Node) error {
@@ -29,39 +45,312 @@ func (ns *SubsectionNode) Apply(n Node) error {
 	return nil
 }
 
+func (ns *SubsectionNode) clone() Node {
+	c := *ns
+	for _, n := range []*Node{&c.Prototype, &c.Empty, &c.Separator, &c.Before, &c.After} {
+		if *n != nil {
+			*n = (*n).clone()
+		}
+	}
+	return &c
+}
+
 func (ns *SubsectionNode) compile(tc *templateCompiler, depth int, opts *CompileOptions) error {
-	m := tc.bindings.Nest(ns.Name)
+	m, err := tc.bindings.TryNest(ns.Name)
+	if err != nil {
+		return err
+	}
 	subsectionOpts := *opts
 	subsectionOpts.RootDepth = depth
-	t, err := Compile(ns.Prototype, m, &subsectionOpts)
+	t, err := compileTemplate(ns.Prototype, m, &subsectionOpts)
 	if err != nil {
 		return err
 	}
-	tc.appendChunk(subsectionChunk{template: *t, bindings: m})
+	meta, err := declaredRowMeta(m)
+	if err != nil {
+		return fmt.Errorf("subsection %s: %w", ns.Name, err)
+	}
+	sc := subsectionChunk{template: *t, bindings: m, meta: meta}
+	// Variants have their own static chunks, and a separator goes between
+	// the end of one row and the start of the next.
+	if !opts.SeparateStaticChunks && len(t.variants) == 0 && ns.Separator == nil {
+		sc.seams = newRowSeams(t.chunks)
+	}
+
+	for _, part := range []struct {
+		name string
+		node Node
+		t    **Template
+	}{
+		{"empty", ns.Empty, &sc.empty},
+		{"separator", ns.Separator, &sc.separator},
+		{"before", ns.Before, &sc.before},
+		{"after", ns.After, &sc.after},
+	} {
+		if part.node == nil {
+			continue
+		}
+		if *part.t, err = compileTemplate(part.node, tc.bindings, &subsectionOpts); err != nil {
+			return fmt.Errorf("compiling %s of subsection %s: %w", part.name, ns.Name, err)
+		}
+	}
+
+	tc.appendChunk(sc)
 	return nil
 }
 
 type subsectionChunk struct {
 	template Template
 	bindings *bindings.Map
+	// Optional, rendered with the parent ValueMap. (See SubsectionNode.)
+	empty, separator, before, after *Template
+	// The row metadata Vars the rows use, or nil if they use none.
+	meta *rowMeta
+	// Optional, used instead of template to render the rows.
+	seams *rowSeams
 }
 
-func (sc subsectionChunk) build(w io.Writer, vm *bindings.ValueMap) error {
-	stream := vm.GetStream(sc.bindings)
+// rowSeams renders rows that start and end with static HTML, so that the end
+// of one row and the start of the next are written together, as one slice.
+type rowSeams struct {
+	head, tail, seam staticChunk
+	// The chunks between head and tail.
+	body []chunk
+}
+
+// newRowSeams returns the rowSeams for rows made of the chunks, or nil if they
+// don't start and end with static chunks.
+func newRowSeams(chunks []chunk) *rowSeams {
+	if len(chunks) < 2 {
+		return nil
+	}
+	head, ok := chunks[0].(staticChunk)
+	if !ok {
+		return nil
+	}
+	tail, ok := chunks[len(chunks)-1].(staticChunk)
+	if !ok {
+		return nil
+	}
+	seam := make([]byte, 0, len(tail.data)+len(head.data))
+	seam = append(append(seam, tail.data...), head.data...)
+	return &rowSeams{head: head, tail: tail, seam: staticChunk{seam}, body: chunks[1 : len(chunks)-1]}
+}
+
+// render renders the i-th row, except for the tail, which the next row writes
+// as part of its seam. The caller must write the tail after the last row.
+func (rs *rowSeams) render(w io.Writer, values *bindings.ValueMap, i int, rc renderContext) error {
+	start := rs.head
+	if i > 0 {
+		start = rs.seam
+	}
+	if err := start.build(w, values, rc); err != nil {
+		return err
+	}
+	for j, c := range rs.body {
+		if err := c.build(w, values, rc); err != nil {
+			return fmt.Errorf("building chunk #%d of %d: %w", j+1, len(rs.body)+2, err)
+		}
+	}
+	return nil
+}
+
+func (sc subsectionChunk) build(w io.Writer, vm *bindings.ValueMap, rc renderContext) error {
+	stream, err := rc.getStream(vm, sc.bindings)
+	if err != nil {
+		return err
+	}
+	if !sc.decorated() && sc.meta == nil && sc.seams == nil {
+		if stream == nil {
+			return nil
+		}
+		return renderStream(w, stream, &sc.template, rc)
+	}
+
+	n, err := sc.eachRow(stream, func(values *bindings.ValueMap, i int) error {
+		return sc.renderRow(w, vm, values, i, rc)
+	})
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return renderOptional(w, sc.empty, vm, rc)
+	}
+	if sc.seams != nil {
+		if err := sc.seams.tail.build(w, vm, rc); err != nil {
+			return err
+		}
+	}
+	return renderOptional(w, sc.after, vm, rc)
+}
+
+// eachRow calls f with every row of the stream, and its index, and returns the
+// number of rows. If the subsection uses row metadata, f gets a copy of the row,
+// with the metadata set, which is only valid until f returns.
+func (sc subsectionChunk) eachRow(stream bindings.ValueStream, f func(values *bindings.ValueMap, i int) error) (int, error) {
 	if stream == nil {
+		return 0, nil
+	}
+	var row *bindings.ValueMap
+	if sc.meta != nil {
+		row = rowPool.Get().(*bindings.ValueMap)
+		defer rowPool.Put(row)
+	}
+	each := func(values *bindings.ValueMap, i, count int, last bool) error {
+		if row == nil {
+			return f(values, i)
+		}
+		if err := sc.meta.set(row, values, i, count, last); err != nil {
+			return err
+		}
+		return f(row, i)
+	}
+
+	if series, ok := stream.(bindings.ValueSeries); ok {
+		for i, values := range series {
+			if err := each(values, i, len(series), i == len(series)-1); err != nil {
+				return i, err
+			}
+		}
+		return len(series), nil
+	}
+
+	// Look one row ahead, to know which row is the last.
+	next := stream.Stream()
+	i := 0
+	for values := next(); values != nil; i++ {
+		if err := checkRow(values, &sc.template); err != nil {
+			return i, err
+		}
+		following := next()
+		if err := each(values, i, -1, following == nil); err != nil {
+			return i, err
+		}
+		values = following
+	}
+	return i, nil
+}
+
+// decorated returns whether the subsection renders anything besides the rows.
+func (sc subsectionChunk) decorated() bool {
+	return sc.empty != nil || sc.separator != nil || sc.before != nil || sc.after != nil
+}
+
+// decorations returns the Templates rendered with the parent ValueMap.
+func (sc subsectionChunk) decorations() []*Template {
+	return []*Template{sc.empty, sc.separator, sc.before, sc.after}
+}
+
+// renderRow renders the i-th row, preceded by Before or Separator.
+func (sc subsectionChunk) renderRow(w io.Writer, vm, values *bindings.ValueMap, i int, rc renderContext) error {
+	between := sc.separator
+	if i == 0 {
+		between = sc.before
+	}
+	if err := renderOptional(w, between, vm, rc); err != nil {
+		return err
+	}
+	if sc.seams != nil {
+		return sc.seams.render(w, values, i, rc)
+	}
+	return sc.template.render(w, values, rc)
+}
+
+// renderOptional renders the Template, if it's not nil.
+func renderOptional(w io.Writer, t *Template, vm *bindings.ValueMap, rc renderContext) error {
+	if t == nil {
+		return nil
+	}
+	return t.render(w, vm, rc)
+}
+
+// renderStream renders the Template once for each row of the stream.
+func renderStream(w io.Writer, stream bindings.ValueStream, t *Template, rc renderContext) error {
+	// ValueSeries is the common case, and ranging over it directly avoids
+	// allocating an iterator.
+	if series, ok := stream.(bindings.ValueSeries); ok {
+		for _, values := range series {
+			if err := t.render(w, values, rc); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 
 	next := stream.Stream()
-	for sectionValues := next(); sectionValues != nil; sectionValues = next() {
-		if err := sc.template.GenerateHTML(w, sectionValues); err != nil {
+	for values := next(); values != nil; values = next() {
+		if err := checkRow(values, t); err != nil {
+			return err
+		}
+		if err := t.render(w, values, rc); err != nil {
 			return err
 		}
 	}
+	return nil
+}
 
+// checkRow returns an error if the row isn't instantiated from the Template's
+// Map. Rows of a ValueSeries are already checked by Map.BindStream, but other
+// ValueStreams can only be checked as they're rendered.
+func checkRow(values *bindings.ValueMap, t *Template) error {
+	if values.Vars != t.Bindings {
+		return fmt.Errorf("%w: row is instantiated from %s, wanted %s", bindings.ErrWrongMap, values.Vars.DebugName(), t.Bindings.DebugName())
+	}
 	return nil
 }
 
+func (sc subsectionChunk) size(vm *bindings.ValueMap, rc renderContext) int {
+	stream := vm.GetStream(sc.bindings)
+	if !sc.decorated() && sc.meta == nil {
+		return streamSize(stream, &sc.template, rc)
+	}
+
+	n := 0
+	rows, _ := sc.eachRow(stream, func(values *bindings.ValueMap, i int) error {
+		n += sc.template.size(values, rc)
+		return nil
+	})
+	if rows == 0 {
+		return n + optionalSize(sc.empty, vm, rc)
+	}
+	return n + optionalSize(sc.before, vm, rc) + (rows-1)*optionalSize(sc.separator, vm, rc) + optionalSize(sc.after, vm, rc)
+}
+
+func optionalSize(t *Template, vm *bindings.ValueMap, rc renderContext) int {
+	if t == nil {
+		return 0
+	}
+	return t.size(vm, rc)
+}
+
+// fingerprint hashes the decorations once: the number of rows, which decides
+// how many times they're rendered, is already in the stream's fingerprint.
+// Rows with metadata are hashed with it set, like they're rendered, because
+// conditions on it decide which of the row's values are used.
+func (sc subsectionChunk) fingerprint(h *fingerprinter, vm *bindings.ValueMap, rc renderContext) {
+	stream := vm.GetStream(sc.bindings)
+	if sc.meta == nil {
+		streamFingerprint(h, stream, &sc.template, rc)
+	} else {
+		sc.eachRow(stream, func(values *bindings.ValueMap, _ int) error {
+			h.writeInt(fingerprintRow)
+			sc.template.fingerprint(h, values, rc)
+			return nil
+		})
+		h.writeInt(fingerprintEnd)
+	}
+	if !sc.decorated() {
+		return
+	}
+	for _, t := range sc.decorations() {
+		if t == nil {
+			h.writeInt(fingerprintNone)
+			continue
+		}
+		t.fingerprint(h, vm, rc)
+	}
+}
+
 // func (sc subsectionChunk) String() string {
 // 	var sb strings.Builder
 // 	fmt.Fprintf(&sb, "subsection(%v) {\n", sc.bindings)
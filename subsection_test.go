@@ -80,6 +80,68 @@ func TestSubsection(t *testing.T) {
 			},
 			output: "John says Hello!Love!Good to see you!\nJane says Howdy!\n",
 		},
+		{
+			comment: "decorated",
+			input: &SubsectionNode{
+				Name:      "tags",
+				Prototype: &TextNode{Value: bindings.Declare("tag", safe.Default)},
+				Empty:     &TextNode{Value: safe.Const("no tags")},
+				Separator: &TextNode{Value: safe.Const(", ")},
+				Before:    &TextNode{Value: safe.Const("tags: ")},
+				After:     &TextNode{Value: safe.Const(".")},
+			},
+			opts: &Compact,
+			values: []bindings.BindArg{
+				{
+					Name: "tags",
+					NestedRows: [][]bindings.BindArg{
+						{{Name: "tag", Value: safe.Const("go")}},
+						{{Name: "tag", Value: safe.Const("html")}},
+						{{Name: "tag", Value: safe.Const("templates")}},
+					},
+				},
+			},
+			output: "tags: go, html, templates.",
+		},
+		{
+			comment: "decorated one row",
+			input: &SubsectionNode{
+				Name:      "tags",
+				Prototype: &TextNode{Value: bindings.Declare("tag", safe.Default)},
+				Empty:     &TextNode{Value: safe.Const("no tags")},
+				Separator: &TextNode{Value: safe.Const(", ")},
+				Before:    &TextNode{Value: safe.Const("tags: ")},
+				After:     &TextNode{Value: safe.Const(".")},
+			},
+			opts: &Compact,
+			values: []bindings.BindArg{
+				{Name: "tags", NestedRows: [][]bindings.BindArg{{{Name: "tag", Value: safe.Const("go")}}}},
+			},
+			output: "tags: go.",
+		},
+		{
+			comment: "empty unset",
+			input: &SubsectionNode{
+				Name:      "tags",
+				Prototype: &TextNode{Value: bindings.Declare("tag", safe.Default)},
+				Empty:     &TextNode{Value: safe.Const("no tags")},
+				Before:    &TextNode{Value: safe.Const("tags: ")},
+				After:     &TextNode{Value: safe.Const(".")},
+			},
+			opts:   &Compact,
+			output: "no tags",
+		},
+		{
+			comment: "empty with page values",
+			input: &SubsectionNode{
+				Name:      "tags",
+				Prototype: &TextNode{Value: bindings.Declare("tag", safe.Default)},
+				Empty:     &TextNode{Value: bindings.Declare("no_tags", safe.Default)},
+			},
+			opts:   &Compact,
+			values: []bindings.BindArg{{Name: "no_tags", Value: safe.Const("nothing here")}},
+			output: "nothing here",
+		},
 	} {
 		t.Run(tc.comment, func(t *testing.T) {
 			if diff := cmp.Diff(tc.output, mustGenerateHTML(t, tc.input, tc.opts, tc.values)); diff != "" {
@@ -14,9 +14,53 @@ type Case struct {
 
 type Condition func(*bindings.ValueMap) bool
 
+// IsSet returns a Condition that's true if the Var has a value, or a default, in
+// the ValueMap. (See bindings.ValueMap.Lookup.) Combined with a default Case, it
+// can be used to render a fallback for missing values.
+//
+// The Var doesn't have to be attached - free Vars are looked up by name.
+func IsSet(v bindings.Var) Condition {
+	return func(vm *bindings.ValueMap) bool {
+		attached, ok := attachedVar(vm, v)
+		if !ok {
+			return false
+		}
+		_, ok = vm.Lookup(attached)
+		return ok
+	}
+}
+
+// IsTrue returns a Condition that's true if the Var was set to true with
+// bindings.Var.BindBool.
+//
+// The Var doesn't have to be attached - free Vars are looked up by name.
+func IsTrue(v bindings.Var) Condition {
+	return func(vm *bindings.ValueMap) bool {
+		attached, ok := attachedVar(vm, v)
+		if !ok {
+			return false
+		}
+		b, _ := vm.GetBool(attached)
+		return b
+	}
+}
+
+// attachedVar returns v if it's attached, or else the Var with the same name in
+// the ValueMap's Map, if there is one.
+func attachedVar(vm *bindings.ValueMap, v bindings.Var) (bindings.Var, bool) {
+	if v.Attached() {
+		return v, true
+	}
+	return vm.Vars.Lookup(v.Name())
+}
+
 type SwitchNode struct {
 	Cases   []Case
 	Default Node
+	// Vars are declared in the Map, even if no case outputs them. Conditions
+	// don't declare the Vars they read, so this is needed to bind a Var that's
+	// only used by a Condition.
+	Vars []bindings.Var
 }
 
 func (sn *SwitchNode) Apply(n Node) error {
@@ -31,7 +75,30 @@ func (sn *SwitchNode) Apply(n Node) error {
 	return nil
 }
 
+func (sn *SwitchNode) clone() Node {
+	c := &SwitchNode{Cases: make([]Case, len(sn.Cases))}
+	for i, cs := range sn.Cases {
+		c.Cases[i] = Case{Condition: cs.Condition}
+		if cs.Output != nil {
+			c.Cases[i].Output = cs.Output.clone()
+		}
+	}
+	if sn.Default != nil {
+		c.Default = sn.Default.clone()
+	}
+	if sn.Vars != nil {
+		c.Vars = append([]bindings.Var(nil), sn.Vars...)
+	}
+	return c
+}
+
 func (sn *SwitchNode) compile(tc *templateCompiler, depth int, opts *CompileOptions) error {
+	for _, v := range sn.Vars {
+		if _, err := tc.bindings.TryAttach(v, v.TrustLevel()); err != nil {
+			return err
+		}
+	}
+
 	sc := switchChunk{
 		conditions: make([]Condition, len(sn.Cases)),
 		templates:  make([]*Template, len(sn.Cases)+1),
@@ -44,7 +111,7 @@ func (sn *SwitchNode) compile(tc *templateCompiler, depth int, opts *CompileOpti
 		if c.Output == nil {
 			continue
 		}
-		t, err := Compile(c.Output, tc.bindings, &nestedOpts)
+		t, err := compileTemplate(c.Output, tc.bindings, &nestedOpts)
 		if err != nil {
 			return fmt.Errorf("compiling case %d/%d: %w", i+1, len(sn.Cases), err)
 		}
@@ -52,7 +119,7 @@ func (sn *SwitchNode) compile(tc *templateCompiler, depth int, opts *CompileOpti
 	}
 
 	if sn.Default != nil {
-		t, err := Compile(sn.Default, tc.bindings, &nestedOpts)
+		t, err := compileTemplate(sn.Default, tc.bindings, &nestedOpts)
 		if err != nil {
 			return fmt.Errorf("compiling default case: %w", err)
 		}
@@ -68,18 +135,35 @@ type switchChunk struct {
 	templates  []*Template
 }
 
-func (sc switchChunk) build(w io.Writer, vm *bindings.ValueMap) error {
+func (sc switchChunk) build(w io.Writer, vm *bindings.ValueMap, rc renderContext) error {
+	if t := sc.pick(vm); t != nil {
+		return t.render(w, vm, rc)
+	}
+	return nil
+}
+
+func (sc switchChunk) size(vm *bindings.ValueMap, rc renderContext) int {
+	if t := sc.pick(vm); t != nil {
+		return t.size(vm, rc)
+	}
+	return 0
+}
+
+func (sc switchChunk) fingerprint(h *fingerprinter, vm *bindings.ValueMap, rc renderContext) {
+	if t := sc.pick(vm); t != nil {
+		t.fingerprint(h, vm, rc)
+		return
+	}
+	h.writeInt(fingerprintNone)
+}
+
+// pick returns the Template of the first case whose condition is true, or the
+// default case. It returns nil if the case has no output.
+func (sc switchChunk) pick(vm *bindings.ValueMap) *Template {
 	for i, c := range sc.conditions {
 		if c(vm) {
-			if t := sc.templates[i]; t != nil {
-				return t.GenerateHTML(w, vm)
-			}
-			return nil
+			return sc.templates[i]
 		}
 	}
-
-	if t := sc.templates[len(sc.templates)-1]; t != nil {
-		return t.GenerateHTML(w, vm)
-	}
-	return nil
+	return sc.templates[len(sc.templates)-1]
 }
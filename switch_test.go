@@ -0,0 +1,108 @@
+package html5
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestSwitchNode(t *testing.T) {
+	author := bindings.Declare("author", safe.Default)
+	input := Element("p", &SwitchNode{
+		Cases:   []Case{{Condition: IsSet(author), Output: Text(safe.Const("By "), author)}},
+		Default: Text(safe.Const("Anonymous")),
+	})
+
+	for _, tc := range []struct {
+		comment string
+		values  []bindings.BindArg
+		output  string
+	}{
+		{
+			comment: "set",
+			values:  []bindings.BindArg{{Name: "author", Value: safe.Const("Adam")}},
+			output:  "<p>By Adam</p>",
+		},
+		{
+			comment: "set to empty string",
+			values:  []bindings.BindArg{{Name: "author", Value: safe.Const("")}},
+			output:  "<p>By </p>",
+		},
+		{
+			comment: "unset",
+			output:  "<p>Anonymous</p>",
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			if diff := cmp.Diff(tc.output, mustGenerateHTML(t, input, &Compact, tc.values)); diff != "" {
+				t.Errorf("GenerateHTML(%v, %v, %v)\n => (-)wanted vs (+)got:\n%s", input, &Compact, tc.values, diff)
+			}
+		})
+	}
+}
+
+func TestSwitchNodeTypedConditions(t *testing.T) {
+	var m bindings.Map
+	count := m.Declare("count", safe.Default)
+	archived := m.Declare("archived", safe.Default)
+	input := Multi(
+		&SwitchNode{
+			Cases: []Case{
+				{
+					Condition: func(vm *bindings.ValueMap) bool {
+						n, _ := vm.GetInt(count)
+						return n == 0
+					},
+					Output: Text(safe.Const("No comments")),
+				},
+			},
+			Default: Text(count, safe.Const(" comments")),
+		},
+		&SwitchNode{Cases: []Case{{Condition: IsTrue(archived), Output: Text(safe.Const(" (archived)"))}}})
+	tmpl := MustCompile(input, &m, &Compact)
+
+	for _, tc := range []struct {
+		vm   *bindings.ValueMap
+		want string
+	}{
+		{vm: m.MustBind(count.BindInt(0)), want: "No comments"},
+		{vm: m.MustBind(count.BindInt(3), archived.BindBool(false)), want: "3 comments"},
+		{vm: m.MustBind(count.BindInt(1), archived.BindBool(true)), want: "1 comments (archived)"},
+	} {
+		var sb strings.Builder
+		if err := tmpl.GenerateHTML(&sb, tc.vm); err != nil {
+			t.Fatalf("GenerateHTML(%v): %v", tc.vm, err)
+		}
+		if diff := cmp.Diff(tc.want, sb.String()); diff != "" {
+			t.Errorf("GenerateHTML(%v) => (-)wanted vs (+)got:\n%s", tc.vm, diff)
+		}
+	}
+}
+
+func TestSwitchNodeVars(t *testing.T) {
+	// The Var is only read by the Condition, so it's not declared unless it's
+	// listed in Vars.
+	featured := bindings.Declare("featured", safe.Default)
+	input := Element("p", &SwitchNode{
+		Cases:   []Case{{Condition: IsTrue(featured), Output: Text(safe.Const("Featured"))}},
+		Default: Text(safe.Const("Regular")),
+		Vars:    []bindings.Var{featured},
+	})
+
+	var m bindings.Map
+	tmpl := MustCompile(input, &m, &Compact)
+	v, ok := m.Lookup("featured")
+	if !ok {
+		t.Fatalf("Compile(%v) didn't declare the Var featured", input)
+	}
+	var sb strings.Builder
+	if err := tmpl.GenerateHTML(&sb, m.MustBind(v.BindBool(true))); err != nil {
+		t.Fatalf("GenerateHTML: %v", err)
+	}
+	if diff := cmp.Diff("<p>Featured</p>", sb.String()); diff != "" {
+		t.Errorf("GenerateHTML(%v) => (-)wanted vs (+)got:\n%s", input, diff)
+	}
+}
@@ -0,0 +1,128 @@
+package html5
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+// Column is a column of a TableNode.
+type Column struct {
+	// Header is the text of the column's <th>: a safe.String, a Var or a
+	// Translatable. Optional.
+	Header Value
+	// Cell is the content of the column's <td>. It's compiled in the rows'
+	// nested Map, so its Vars are bound per row.
+	Cell Node
+	// Var is the text of the column's <td>, if there's no Cell. Like Cell, it's
+	// bound per row.
+	Var bindings.Var
+	// Class, if set, is the class attribute of the column's <th> and <td>s.
+	Class safe.String
+	// SortKey, if set, makes the header a link to the URL that
+	// TableNode.SortURL returns for it.
+	SortKey string
+}
+
+// TableNode is a <table> with a header row, and a body row for every ValueMap
+// in the ValueStream of the nested Map Name. The body rows are a
+// SubsectionNode, so their Vars are declared in the nested Map.
+type TableNode struct {
+	Name    string
+	Columns []Column
+	// Empty, if set, is rendered in a single row spanning all the columns,
+	// when the stream has no rows, or is unset.
+	Empty Node
+	// SortURL returns the link of the headers of columns with a SortKey. The
+	// URL can be a safe.String, or a Var, so that it can depend on the
+	// current sort order. Required if any Column has a SortKey.
+	SortURL func(key string) Value
+}
+
+// Table returns a TableNode with the given nested Map name and columns.
+func Table(name string, columns ...Column) *TableNode {
+	return &TableNode{Name: name, Columns: columns}
+}
+
+func (tn *TableNode) Apply(n Node) error {
+	switch n := n.(type) {
+	case *ElementNode:
+		n.Contents = append(n.Contents, tn)
+	case *MultiNode:
+		n.Contents = append(n.Contents, tn)
+	default:
+		return fmt.Errorf("TableNode can only be applied to ElementNode or MultiNode, got %v", n)
+	}
+	return nil
+}
+
+func (tn *TableNode) clone() Node {
+	c := *tn
+	c.Columns = make([]Column, len(tn.Columns))
+	for i, col := range tn.Columns {
+		c.Columns[i] = col
+		if col.Cell != nil {
+			c.Columns[i].Cell = col.Cell.clone()
+		}
+	}
+	if tn.Empty != nil {
+		c.Empty = tn.Empty.clone()
+	}
+	return &c
+}
+
+func (tn *TableNode) compile(tc *templateCompiler, depth int, opts *CompileOptions) error {
+	e, err := tn.element()
+	if err != nil {
+		return fmt.Errorf("table %q: %w", tn.Name, err)
+	}
+	return e.compile(tc, depth, opts)
+}
+
+// element returns the markup of the table.
+func (tn *TableNode) element() (*ElementNode, error) {
+	header := Element("tr")
+	row := Element("tr")
+	for i, col := range tn.Columns {
+		th := Element("th")
+		td := Element("td")
+		if col.Class != nil {
+			Attribute("class", col.Class).Apply(th)
+			Attribute("class", col.Class).Apply(td)
+		}
+
+		if col.Header != nil {
+			var text Node = &TextNode{Value: col.Header}
+			if col.SortKey != "" {
+				if tn.SortURL == nil {
+					return nil, fmt.Errorf("column %d has a SortKey, but there's no SortURL", i)
+				}
+				text = Element("a", Attribute("href", tn.SortURL(col.SortKey)), text)
+			}
+			text.Apply(th)
+		}
+
+		switch {
+		case col.Cell != nil:
+			col.Cell.Apply(td)
+		case col.Var != bindings.ZeroVar:
+			Text(col.Var).Apply(td)
+		}
+		header.Contents = append(header.Contents, th)
+		row.Contents = append(row.Contents, td)
+	}
+
+	rows := &SubsectionNode{Name: tn.Name, Prototype: row}
+	if tn.Empty != nil {
+		rows.Empty = Element("tr", Element("td",
+			Attribute("colspan", safe.Bless(safe.AttributeSafe, strconv.Itoa(len(tn.Columns)))),
+			tn.Empty))
+	}
+	return Element("table", Element("thead", header), Element("tbody", rows)), nil
+}
+
+func (tn *TableNode) String() string {
+	return fmt.Sprintf("Table(%q, %d columns)", tn.Name, len(tn.Columns))
+}
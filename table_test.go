@@ -0,0 +1,77 @@
+package html5
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/the80srobot/html5/bindings"
+	"github.com/the80srobot/html5/safe"
+)
+
+func TestTable(t *testing.T) {
+	table := &TableNode{
+		Name: "users",
+		Columns: []Column{
+			{Header: safe.Const("Name"), Var: bindings.Declare("name", safe.Default), SortKey: "name"},
+			{
+				Header: safe.Const("Email"),
+				Cell:   Element("a", Attribute("href", bindings.Declare("mailto", safe.URLSafe)), Text(bindings.Declare("email", safe.Default))),
+				Class:  safe.Const("email"),
+			},
+		},
+		Empty: Text(safe.Const("No users")),
+		SortURL: func(key string) Value {
+			return bindings.Declare("sort_"+key, safe.URLSafe)
+		},
+	}
+
+	var m bindings.Map
+	tmpl := MustCompile(table, &m, &Compact)
+	sortName, _ := m.Lookup("sort_name")
+	users := m.Nest("users")
+	name, _ := users.Lookup("name")
+	mailto, _ := users.Lookup("mailto")
+	email, _ := users.Lookup("email")
+
+	header := `<table><thead><tr><th><a href="?sort=-name">Name</a></th><th class="email">Email</th></tr></thead>`
+	for _, tc := range []struct {
+		comment string
+		rows    []*bindings.ValueMap
+		want    string
+	}{
+		{
+			comment: "rows",
+			rows: []*bindings.ValueMap{
+				users.MustBind(name.BindConst("Adam"), mailto.BindConst("mailto:adam@example.com"), email.BindConst("adam@example.com")),
+				users.MustBind(name.BindConst("Eve"), mailto.BindConst("mailto:eve@example.com"), email.BindConst("eve@example.com")),
+			},
+			want: header + `<tbody>` +
+				`<tr><td>Adam</td><td class="email"><a href="mailto:adam@example.com">adam@example.com</a></td></tr>` +
+				`<tr><td>Eve</td><td class="email"><a href="mailto:eve@example.com">eve@example.com</a></td></tr>` +
+				`</tbody></table>`,
+		},
+		{
+			comment: "empty",
+			want:    header + `<tbody><tr><td colspan="2">No users</td></tr></tbody></table>`,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			vm := m.MustBind(sortName.BindConst("?sort=-name"), users.BindSeries(tc.rows...))
+			var sb strings.Builder
+			if err := tmpl.GenerateHTML(&sb, vm); err != nil {
+				t.Fatalf("GenerateHTML: %v", err)
+			}
+			if diff := cmp.Diff(tc.want, sb.String()); diff != "" {
+				t.Errorf("GenerateHTML(%v) => (-)wanted vs (+)got:\n%s", table, diff)
+			}
+		})
+	}
+}
+
+func TestTableSortKeyWithoutURL(t *testing.T) {
+	table := Table("rows", Column{Header: safe.Const("Name"), SortKey: "name"})
+	if _, err := Compile(table, &bindings.Map{}, &Compact); err == nil {
+		t.Errorf("Compile(%v) => no error, wanted one for the missing SortURL", table)
+	}
+}
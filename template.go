@@ -1,6 +1,8 @@
 package html5
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"fmt"
 	"io"
 	"strings"
@@ -11,11 +13,25 @@ import (
 type Node interface {
 	Content
 	compile(tc *templateCompiler, depth int, opts *CompileOptions) error
+	// clone returns a deep copy of the Node. Immutable parts, such as Values
+	// and compiled Templates, may be shared with the original.
+	clone() Node
 }
 
 type Template struct {
 	Bindings *bindings.Map
 	chunks   []chunk
+	// Chunks with translations merged in, by normalized locale. Only set if
+	// CompileOptions.Locales was set, and the Template has Translatable text.
+	variants map[string][]chunk
+	// Whether the Template, or any Template nested in it, has a DeferredNode.
+	deferred bool
+	// Whether the Template, or any Template nested in it, has a FlushPoint.
+	flushes bool
+	// Hash of the static chunks. (See Fingerprint.)
+	digest [sha256.Size]byte
+	// Standalone Templates of the FragmentNodes, by ID.
+	fragments map[string]*Template
 }
 
 func GenerateHTML(w io.Writer, t *Template, values ...bindings.BindArg) error {
@@ -30,10 +46,52 @@ func GenerateHTML(w io.Writer, t *Template, values ...bindings.BindArg) error {
 	return t.GenerateHTML(w, vm)
 }
 
+// GenerateHTML writes the HTML for the Template, with values from the
+// ValueMap, which must be instantiated from t.Bindings. It's the same as
+// calling Render with default RenderOptions.
 func (t *Template) GenerateHTML(w io.Writer, vm *bindings.ValueMap) error {
-	for i, chunk := range t.chunks {
-		if err := chunk.build(w, vm); err != nil {
-			return fmt.Errorf("building chunk #%d of %d: %w", i, len(t.chunks), err)
+	return t.Render(w, vm, &defaultRenderOptions)
+}
+
+// Render is like GenerateHTML, but allows the caller to specify
+// RenderOptions.
+func (t *Template) Render(w io.Writer, vm *bindings.ValueMap, opts *RenderOptions) error {
+	if opts.Validate {
+		if err := vm.Validate(); err != nil {
+			return err
+		}
+	}
+	rc := t.newRenderContext(vm, opts)
+	switch w := w.(type) {
+	case *bytes.Buffer:
+		// Buffers can be grown once, up front. Sizing deferred content would
+		// wait for it, and there's no point, because it's not streamed anyway.
+		if opts.Presize && !t.deferred {
+			w.Grow(t.size(vm, rc))
+		} else {
+			w.Grow(t.StaticSize())
+		}
+	}
+	return t.renderPage(w, vm, rc)
+}
+
+// renderPage renders the Template at the root of the page, followed by any
+// deferred content that wasn't rendered in the <body>.
+func (t *Template) renderPage(w io.Writer, vm *bindings.ValueMap, rc renderContext) error {
+	if err := t.render(w, vm, rc); err != nil {
+		return err
+	}
+	if rc.state != nil {
+		return rc.state.drain(w, rc)
+	}
+	return nil
+}
+
+func (t *Template) render(w io.Writer, vm *bindings.ValueMap, rc renderContext) error {
+	chunks := t.variant(rc.locale)
+	for i, chunk := range chunks {
+		if err := chunk.build(w, vm, rc); err != nil {
+			return fmt.Errorf("building chunk #%d of %d: %w", i, len(chunks), err)
 		}
 	}
 	return nil
@@ -45,20 +103,58 @@ func (t *Template) String() string {
 	for i, c := range t.chunks {
 		fmt.Fprintf(&sb, "\tchunk %d/%d: %v\n", i+1, len(t.chunks), c)
 	}
+	if len(t.variants) != 0 {
+		fmt.Fprintf(&sb, "\t%d locale variants: %v\n", len(t.variants), t.Locales())
+	}
+	if len(t.fragments) != 0 {
+		fmt.Fprintf(&sb, "\t%d fragments: %v\n", len(t.fragments), t.Fragments())
+	}
 	sb.WriteString("\n\t-- bindings follow after this line --\n\n")
 	t.Bindings.DebugDump(&sb, 1)
 	sb.WriteByte('}')
 	return sb.String()
 }
 
+// Compile the Node tree into a Template. Vars and nested Maps used by the tree
+// are declared in the provided Map, which is then frozen. (See
+// bindings.Map.Freeze.)
+//
+// Compile doesn't modify the Node tree, so the same tree can be compiled many
+// times, even concurrently, with different Maps and options.
 func Compile(n Node, m *bindings.Map, opts *CompileOptions) (*Template, error) {
+	t, err := compileTemplate(n, m, opts)
+	if err != nil {
+		return nil, err
+	}
+	m.Freeze()
+	return t, nil
+}
+
+// compileTemplate is like Compile, but doesn't freeze the Map. Nodes that
+// compile nested Templates use this, because the Map is still being populated.
+func compileTemplate(n Node, m *bindings.Map, opts *CompileOptions) (*Template, error) {
 	tc := &templateCompiler{bindings: m}
 	tc.separateChunks = opts.SeparateStaticChunks
+	tc.catalog = opts.Catalog
 	if err := n.compile(tc, opts.RootDepth, opts); err != nil {
 		return nil, err
 	}
 	tc.flush()
-	return &Template{chunks: tc.chunks, Bindings: tc.bindings}, nil
+	if err := collectFragments(&tc.fragments, tc.chunks); err != nil {
+		return nil, err
+	}
+	t := &Template{
+		chunks:    tc.chunks,
+		Bindings:  tc.bindings,
+		deferred:  hasDeferred(tc.chunks),
+		flushes:   hasFlush(tc.chunks),
+		digest:    staticDigest(tc.chunks),
+		fragments: tc.fragments,
+	}
+	if err := t.compileVariants(opts); err != nil {
+		return nil, err
+	}
+	return t, nil
 }
 
 func MustCompile(n Node, m *bindings.Map, opts *CompileOptions) *Template {
@@ -70,18 +166,94 @@ func MustCompile(n Node, m *bindings.Map, opts *CompileOptions) *Template {
 }
 
 type chunk interface {
-	build(w io.Writer, vm *bindings.ValueMap) error
+	build(w io.Writer, vm *bindings.ValueMap, rc renderContext) error
+	// size returns the number of bytes build would write. Unset values count
+	// as empty, even if rendering is strict.
+	size(vm *bindings.ValueMap, rc renderContext) int
+	// fingerprint hashes the values build would write. Static chunks are
+	// covered by Template.digest, and write nothing.
+	fingerprint(h *fingerprinter, vm *bindings.ValueMap, rc renderContext)
+}
+
+// nestedTemplates returns the Templates nested in the chunk, if any. Some may
+// be nil.
+func nestedTemplates(c chunk) []*Template {
+	switch c := c.(type) {
+	case subsectionChunk:
+		return append([]*Template{&c.template}, c.decorations()...)
+	case deferredChunk:
+		return []*Template{c.template, c.placeholder}
+	case nestedTemplateChunk:
+		return []*Template{c.template}
+	case mappedTemplateChunk:
+		return []*Template{c.template}
+	case switchChunk:
+		return c.templates
+	}
+	return nil
+}
+
+// renderContext carries the RenderOptions into nested Templates. It's passed
+// by value, so that rendering doesn't allocate.
+type renderContext struct {
+	opts *RenderOptions
+	// The locale of the root ValueMap. Nested ValueMaps don't have their own.
+	locale string
+	// Deferred content. Nil, unless the Template has a DeferredNode.
+	state *renderState
+}
+
+func (t *Template) newRenderContext(vm *bindings.ValueMap, opts *RenderOptions) renderContext {
+	locale := opts.Locale
+	if locale == "" {
+		locale = vm.Locale
+	}
+	rc := renderContext{opts: opts, locale: locale}
+	if t.deferred {
+		rc.state = &renderState{}
+	}
+	return rc
+}
+
+// getString returns the value of the Var, or an error if the Var is unset and
+// rendering is strict.
+func (rc renderContext) getString(vm *bindings.ValueMap, v bindings.Var) (string, error) {
+	s, ok := vm.Lookup(v)
+	if !ok && rc.opts.Strict {
+		return "", fmt.Errorf("%w var %s", bindings.ErrUnset, v.Name())
+	}
+	return s, nil
+}
+
+// getStream returns the ValueStream of the nested Map, or an error if the nested
+// Map is unset and rendering is strict.
+func (rc renderContext) getStream(vm *bindings.ValueMap, m *bindings.Map) (bindings.ValueStream, error) {
+	stream := vm.GetStream(m)
+	if stream == nil && rc.opts.Strict {
+		return nil, fmt.Errorf("%w nested map %s", bindings.ErrUnset, m.DebugName())
+	}
+	return stream, nil
 }
 
 type staticChunk struct {
-	data string
+	data []byte
 }
 
-func (sc staticChunk) build(w io.Writer, _ *bindings.ValueMap) error {
-	_, err := io.WriteString(w, sc.data)
+func (sc staticChunk) build(w io.Writer, _ *bindings.ValueMap, _ renderContext) error {
+	if g, ok := w.(*gatherWriter); ok {
+		g.writeStatic(sc.data)
+		return nil
+	}
+	_, err := w.Write(sc.data)
 	return err
 }
 
+func (sc staticChunk) size(*bindings.ValueMap, renderContext) int {
+	return len(sc.data)
+}
+
+func (staticChunk) fingerprint(*fingerprinter, *bindings.ValueMap, renderContext) {}
+
 func (sc staticChunk) String() string {
 	return fmt.Sprintf("static{%q}", sc.data)
 }
@@ -90,10 +262,22 @@ type stringBindingChunk struct {
 	binding bindings.Var
 }
 
-func (sbc stringBindingChunk) build(w io.Writer, vm *bindings.ValueMap) error {
-	_, err := io.WriteString(w, vm.GetString(sbc.binding))
-	return err
+func (sbc stringBindingChunk) build(w io.Writer, vm *bindings.ValueMap, rc renderContext) error {
+	s, err := rc.getString(vm, sbc.binding)
+	if err != nil {
+		return err
+	}
+	return writeString(w, s)
+}
+
+func (sbc stringBindingChunk) size(vm *bindings.ValueMap, _ renderContext) int {
+	s, _ := vm.Lookup(sbc.binding)
+	return len(s)
+}
 
+func (sbc stringBindingChunk) fingerprint(h *fingerprinter, vm *bindings.ValueMap, _ renderContext) {
+	s, _ := vm.Lookup(sbc.binding)
+	h.writeString(s)
 }
 
 func (sbc stringBindingChunk) String() string {
@@ -1,6 +1,7 @@
 package html5
 
 import (
+	"errors"
 	"strings"
 	"testing"
 
@@ -40,3 +41,82 @@ type valueArg struct {
 	Value       safe.String
 	Subsections [][]valueArg
 }
+
+func TestCompileFreezesMap(t *testing.T) {
+	var m bindings.Map
+	MustCompile(Text(bindings.Declare("title", safe.Default)), &m, &Compact)
+	if !m.Frozen() {
+		t.Fatal("Compile didn't freeze the Map")
+	}
+
+	// Reusing the Map is fine, as long as nothing new is declared.
+	if _, err := Compile(Text(bindings.Declare("title", safe.Default)), &m, &Compact); err != nil {
+		t.Errorf("Compile with a frozen Map and existing Vars: %v", err)
+	}
+	if _, err := Compile(Text(bindings.Declare("body", safe.Default)), &m, &Compact); !errors.Is(err, bindings.ErrFrozen) {
+		t.Errorf("Compile with a frozen Map and a new Var => %v, wanted %v", err, bindings.ErrFrozen)
+	}
+}
+
+func TestRenderStrict(t *testing.T) {
+	var m bindings.Map
+	input := Element("p",
+		Text(bindings.Declare("title", safe.Default)),
+		&SubsectionNode{Name: "tags", Prototype: Text(bindings.Declare("tag", safe.Default))})
+	tmpl := MustCompile(input, &m, &Compact)
+	title, _ := m.Lookup("title")
+	tags := m.Nest("tags")
+
+	for _, tc := range []struct {
+		comment string
+		vm      *bindings.ValueMap
+		wantErr error
+	}{
+		{
+			comment: "everything set",
+			vm:      m.MustBind(title.BindConst("Hello"), tags.BindSeries()),
+		},
+		{
+			comment: "missing var",
+			vm:      m.MustBind(tags.BindSeries()),
+			wantErr: bindings.ErrUnset,
+		},
+		{
+			comment: "missing nested map",
+			vm:      m.MustBind(title.BindConst("Hello")),
+			wantErr: bindings.ErrUnset,
+		},
+	} {
+		t.Run(tc.comment, func(t *testing.T) {
+			var sb strings.Builder
+			if err := tmpl.Render(&sb, tc.vm, &RenderOptions{Strict: true}); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Render(%v) => %v, wanted error %v", tc.vm, err, tc.wantErr)
+			}
+			// Non-strict rendering never fails on missing values.
+			if err := tmpl.GenerateHTML(&sb, tc.vm); err != nil {
+				t.Errorf("GenerateHTML(%v): %v", tc.vm, err)
+			}
+		})
+	}
+}
+
+func TestRenderValidate(t *testing.T) {
+	var m bindings.Map
+	input := &SubsectionNode{Name: "comments", Prototype: Text(bindings.Declare("author", safe.Default))}
+	tmpl := MustCompile(input, &m, &Compact)
+	comments := m.Nest("comments")
+	vm := m.MustBind(comments.BindSeries(comments.MustBind(), comments.MustBind()))
+
+	var sb strings.Builder
+	err := tmpl.Render(&sb, vm, &RenderOptions{Validate: true})
+	var verr *bindings.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("Render(%v) => %v, wanted a *bindings.ValidationError", vm, err)
+	}
+	if len(verr.Missing) != 2 {
+		t.Errorf("Render(%v) => %v, wanted two missing values", vm, err)
+	}
+	if sb.Len() != 0 {
+		t.Errorf("Render(%v) failed validation, but still wrote %q", vm, sb.String())
+	}
+}
@@ -1,11 +1,9 @@
 package html5
 
 import (
-	"bytes"
 	"fmt"
 	"io"
 	"reflect"
-	"strings"
 
 	"github.com/the80srobot/html5/bindings"
 	"github.com/the80srobot/html5/safe"
@@ -13,6 +11,9 @@ import (
 
 type TextNode struct {
 	Value Value
+	// Isolate wraps the text to keep it from affecting the direction of the
+	// surrounding text. (See BidiIsolation.)
+	Isolate BidiIsolation
 }
 
 func Text(contents ...Value) Node {
@@ -43,23 +44,53 @@ func (t *TextNode) Apply(n Node) error {
 }
 
 func (t *TextNode) String() string {
+	if t.Isolate != NoIsolation {
+		return fmt.Sprintf("&TextNode{value=%v, isolate=%v}", t.Value, t.Isolate)
+	}
 	return fmt.Sprintf("&TextNode{value=%v}", t.Value)
 }
 
+func (t *TextNode) clone() Node {
+	c := *t
+	return &c
+}
+
 func (t *TextNode) compile(tc *templateCompiler, depth int, opts *CompileOptions) error {
+	if t.Isolate == NoIsolation {
+		return t.compileValue(tc, depth, opts)
+	}
+	open, close := t.Isolate.delimiters()
+	if _, err := io.WriteString(tc, open); err != nil {
+		return err
+	}
+	if err := t.compileValue(tc, depth, opts); err != nil {
+		return err
+	}
+	_, err := io.WriteString(tc, close)
+	return err
+}
+
+func (t *TextNode) compileValue(tc *templateCompiler, depth int, opts *CompileOptions) error {
 	switch v := t.Value.(type) {
 	case safe.String:
 		s, err := safe.Check(v, safe.TextSafe)
 		if err != nil {
 			return err
 		}
-		return fprintBlockText(tc, depth, opts.TextWidth, opts.Indent, strings.NewReader(s))
+		return fprintBlockText(tc, depth, opts.TextWidth, opts.Indent, s)
+	case Translatable:
+		return tc.appendMessage(v, func(w io.Writer, s string) error {
+			return fprintBlockText(w, depth, opts.TextWidth, opts.Indent, s)
+		})
 	case bindings.Var:
-		v = tc.bindings.Attach(v, safe.TextSafe)
+		v, err := tc.bindings.TryAttach(v, safe.TextSafe)
+		if err != nil {
+			return err
+		}
 		tc.appendChunk(textBindingChunk{TextNode: *t, depth: depth, indent: opts.Indent, binding: v, width: opts.TextWidth})
 		return nil
 	default:
-		return fmt.Errorf("value must be safe.String or *bindings.Var, %v (%v) is neither", v, reflect.TypeOf(v))
+		return fmt.Errorf("value must be safe.String, *bindings.Var or Translatable, %v (%v) is neither", v, reflect.TypeOf(v))
 	}
 }
 
@@ -71,19 +102,31 @@ type textBindingChunk struct {
 	width   int
 }
 
-func (tc textBindingChunk) build(w io.Writer, vm *bindings.ValueMap) error {
-	// An optimization: if we don't need to break up the lines then we can just
-	// print the binding value as is.
-	if tc.width <= 0 {
-		_, err := io.WriteString(w, vm.GetString(tc.binding))
+func (tc textBindingChunk) build(w io.Writer, vm *bindings.ValueMap, rc renderContext) error {
+	s, err := rc.getString(vm, tc.binding)
+	if err != nil {
 		return err
 	}
 
-	var b bytes.Buffer
-	if _, err := io.WriteString(&b, vm.GetString(tc.binding)); err != nil {
-		return err
+	return fprintBlockText(w, tc.depth, tc.width, tc.indent, s)
+}
+
+func (tc textBindingChunk) size(vm *bindings.ValueMap, _ renderContext) int {
+	s, _ := vm.Lookup(tc.binding)
+	if tc.width <= 0 {
+		return len(s)
 	}
-	return fprintBlockText(w, tc.depth, tc.width, tc.indent, &b)
+	// Wrapping replaces runs of whitespace, so the only way to know the size is
+	// to do it.
+	var cw countingWriter
+	fprintBlockText(&cw, tc.depth, tc.width, tc.indent, s)
+	return cw.n
+}
+
+// fingerprint hashes the value before wrapping, which is deterministic.
+func (tc textBindingChunk) fingerprint(h *fingerprinter, vm *bindings.ValueMap, _ renderContext) {
+	s, _ := vm.Lookup(tc.binding)
+	h.writeString(s)
 }
 
 func (tc textBindingChunk) String() string {
@@ -54,6 +54,19 @@ func TestTextNode(t *testing.T) {
 			values:  []bindings.BindArg{{Name: "hello", Value: safe.EscapeText("<p>Hello, World!</p>")}},
 			output:  "&lt;p&gt;Hello, World!&lt;/p&gt;",
 		},
+		{
+			comment: "wrapped words",
+			input:   &TextNode{Value: safe.Const("The quick  brown\tfox jumps over the lazy dog.")},
+			opts:    &CompileOptions{Indent: "  ", TextWidth: 12, RootDepth: 1},
+			output:  "The quick\n  brown fox\n  jumps over\n  the lazy\n  dog.",
+		},
+		{
+			comment: "wrapped binding",
+			input:   &TextNode{Value: bindings.Declare("hello", safe.Default)},
+			opts:    &CompileOptions{TextWidth: 9},
+			values:  []bindings.BindArg{{Name: "hello", Value: safe.EscapeText(" Hello,\n   wide World! ")}},
+			output:  "Hello,\nwide\nWorld!",
+		},
 	} {
 		t.Run(tc.comment, func(t *testing.T) {
 			if diff := cmp.Diff(tc.output, mustGenerateHTML(t, tc.input, tc.opts, tc.values)); diff != "" {